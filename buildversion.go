@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// versionsFromFile reads the version of the build from a Maven pom.xml, a Gradle gradle.properties, an npm
+// package.json or, for any other file name, a plain file holding just the version, such as VERSION.  It returns
+// that version without its -SNAPSHOT suffix as the release version, and the release version with its last
+// number incremented as the next version.
+func versionsFromFile(path string) (release, next string, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	var version string
+	switch filepath.Base(path) {
+	case "pom.xml":
+		version, err = pomVersion(data)
+	case "gradle.properties":
+		version, err = gradleVersion(data)
+	case "package.json":
+		version, err = packageVersion(data)
+	default:
+		version = strings.TrimSpace(firstLine(data))
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("error reading version from %s: %v", path, err)
+	}
+	if version == "" {
+		return "", "", fmt.Errorf("%s has no version", path)
+	}
+
+	release = nextVersion(version)
+	next, err = incrementVersion(release)
+	if err != nil {
+		return "", "", fmt.Errorf("error computing next version from %s: %v", path, err)
+	}
+	return release, next, nil
+}
+
+// pomVersion returns the project version of a pom.xml, which is inherited from the parent if not given.
+func pomVersion(data []byte) (string, error) {
+	var pom struct {
+		Version string `xml:"version"`
+		Parent  struct {
+			Version string `xml:"version"`
+		} `xml:"parent"`
+	}
+	if err := xml.Unmarshal(data, &pom); err != nil {
+		return "", err
+	}
+	version := strings.TrimSpace(pom.Version)
+	if version == "" {
+		version = strings.TrimSpace(pom.Parent.Version)
+	}
+	if strings.Contains(version, "${") {
+		return "", fmt.Errorf("version %s refers to a property", version)
+	}
+	return version, nil
+}
+
+// gradleVersion returns the version property of a gradle.properties.
+func gradleVersion(data []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			continue
+		}
+		if strings.TrimSpace(line[:i]) == "version" {
+			return strings.TrimSpace(line[i+1:]), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// packageVersion returns the version of a package.json.
+func packageVersion(data []byte) (string, error) {
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "", err
+	}
+	return pkg.Version, nil
+}
+
+func firstLine(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// incrementVersion returns the version with its last number incremented, for example 1.10 for 1.9.
+func incrementVersion(version string) (string, error) {
+	i := strings.LastIndex(version, ".") + 1
+	n, err := strconv.Atoi(version[i:])
+	if err != nil {
+		return "", fmt.Errorf("version %s does not end in a number", version)
+	}
+	return version[:i] + strconv.Itoa(n+1), nil
+}
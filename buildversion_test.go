@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestVersionsFromFile(t *testing.T) {
+	var tests = []struct {
+		name    string
+		content string
+		release string
+		next    string
+	}{
+		{"pom.xml", `<?xml version="1.0"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <parent><version>9.9</version></parent>
+  <dependencies><dependency><version>3.0</version></dependency></dependencies>
+  <version>1.1-SNAPSHOT</version>
+</project>`, "1.1", "1.2"},
+		{"pom.xml", `<project><parent><version>2.0.9</version></parent></project>`, "2.0.9", "2.0.10"},
+		{"gradle.properties", "# build\ngroup=com.xoom\nversion = 3.4-SNAPSHOT\n", "3.4", "3.5"},
+		{"package.json", `{"name": "svc", "version": "0.9.0"}`, "0.9.0", "0.9.1"},
+		{"VERSION", "\n7\n", "7", "8"},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), test.name)
+		if err := ioutil.WriteFile(path, []byte(test.content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v\n", err)
+		}
+		release, next, err := versionsFromFile(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v\n", test.name, err)
+		}
+		if release != test.release || next != test.next {
+			t.Fatalf("%s: want %s %s but got %s %s\n", test.name, test.release, test.next, release, next)
+		}
+	}
+}
+
+func TestVersionsFromFileErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"pom.xml":           `<project><version>${revision}</version></project>`,
+		"gradle.properties": "group=com.xoom\n",
+		"VERSION":           "1.0-rc\n",
+	} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v\n", err)
+		}
+		if _, _, err := versionsFromFile(path); err == nil {
+			t.Fatalf("%s: expected an error\n", name)
+		}
+	}
+}
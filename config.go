@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// A config holds named profiles of flag values, for example the Jira base URL, credentials and project key of
+// one Jira instance.
+type config struct {
+	// DefaultProfile is the profile used when -profile is not given.  Optional.
+	DefaultProfile string `yaml:"default-profile"`
+	// Profiles maps profile names to flag values, keyed by flag name without the leading dash.
+	Profiles map[string]map[string]flagValues `yaml:"profiles"`
+}
+
+// flagValues are the values of a flag in a profile: one value, or a list of them for a flag that may be repeated.
+type flagValues []string
+
+func (v *flagValues) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var one string
+	if err := unmarshal(&one); err == nil {
+		*v = flagValues{one}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*v = list
+	return nil
+}
+
+// defaultConfigPath returns the path of the config file read when -config is not given, or the empty string if
+// there is no home directory.
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "kraken", "config.yaml")
+}
+
+// applyConfig sets the flags that were not given on the command line to the values of the selected profile of
+// the config file.  The config file is -config, or the default config file if it exists.
+func applyConfig(fs *flag.FlagSet, path, profile string) error {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+	if path == "" {
+		return nil
+	}
+	c, err := readConfig(path)
+	if os.IsNotExist(err) && !explicit {
+		if profile != "" {
+			return fmt.Errorf("profile %s requires a config file, %s does not exist", profile, path)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if profile == "" {
+		profile = c.DefaultProfile
+	}
+	if profile == "" {
+		return nil
+	}
+	values, present := c.Profiles[profile]
+	if !present {
+		return fmt.Errorf("config %s has no profile %s", path, profile)
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch {
+		case fs.Lookup(name) == nil:
+			return fmt.Errorf("config %s profile %s: unknown flag %s", path, profile, name)
+		case name == "config" || name == "profile":
+			return fmt.Errorf("config %s profile %s: %s cannot be set by a profile", path, profile, name)
+		case set[name]:
+			continue
+		}
+		for _, value := range values[name] {
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("config %s profile %s: %s: %v", path, profile, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func readConfig(path string) (config, error) {
+	var c config
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return c, fmt.Errorf("error parsing config %s: %v", path, err)
+	}
+	return c, nil
+}
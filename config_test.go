@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"testing"
+)
+
+func TestApplyConfig(t *testing.T) {
+	path := writeTemp(t, `
+default-profile: plat
+profiles:
+  plat:
+    jira-base-url: https://jira.example.com
+    project-key: PLAT
+    parallelism: 4
+    job-name-pattern:
+      - ^team-(?P<component>.+)-release-hotfix$
+      - ^(?P<component>[^-]+)-release$
+  bp:
+    project-key: BP
+`)
+	defer os.Remove(path)
+
+	fs := flag.NewFlagSet("kraken", flag.ContinueOnError)
+	baseURL := fs.String("jira-base-url", "http://localhost:8080", "")
+	projectKey := fs.String("project-key", "", "")
+	parallelism := fs.Int("parallelism", 1, "")
+	var patterns patternList
+	fs.Var(&patterns, "job-name-pattern", "")
+	if err := fs.Parse([]string{"-project-key", "OVERRIDE"}); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	if err := applyConfig(fs, path, ""); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if *baseURL != "https://jira.example.com" || *parallelism != 4 {
+		t.Fatalf("Want the plat profile values but got %s %d\n", *baseURL, *parallelism)
+	}
+	if len(patterns) != 2 {
+		t.Fatalf("Want 2 job name patterns but got %d\n", len(patterns))
+	}
+	if *projectKey != "OVERRIDE" {
+		t.Fatalf("Want the command line to win but got %s\n", *projectKey)
+	}
+
+	if err := applyConfig(fs, path, "nope"); err == nil {
+		t.Fatalf("Expected an error for a missing profile\n")
+	}
+}
+
+func TestApplyConfigUnknownFlag(t *testing.T) {
+	path := writeTemp(t, `
+profiles:
+  plat:
+    jira-bsae-url: https://jira.example.com
+`)
+	defer os.Remove(path)
+
+	fs := flag.NewFlagSet("kraken", flag.ContinueOnError)
+	fs.String("jira-base-url", "", "")
+	if err := applyConfig(fs, path, "plat"); err == nil {
+		t.Fatalf("Expected an error for an unknown flag\n")
+	}
+}
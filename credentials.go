@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// netrcEntry is the login and password of a machine in a .netrc file.
+type netrcEntry struct {
+	login    string
+	password string
+}
+
+// resolveCredentials fills in the Jira username, password and token the flags leave empty from, in order, the
+// KRAKEN_JIRA_* environment variables, -jira-password-file, and the ~/.netrc entry for the host of -jira-base-url.
+func resolveCredentials() error {
+	if *username == "" {
+		*username = os.Getenv("KRAKEN_JIRA_USERNAME")
+	}
+	if *password == "" {
+		*password = os.Getenv("KRAKEN_JIRA_PASSWORD")
+	}
+	if *token == "" {
+		*token = os.Getenv("KRAKEN_JIRA_TOKEN")
+	}
+
+	if *password == "" && *passwordFile != "" {
+		data, err := ioutil.ReadFile(*passwordFile)
+		if err != nil {
+			return fmt.Errorf("error reading jira-password-file: %v", err)
+		}
+		*password = strings.TrimRight(string(data), "\r\n")
+	}
+
+	if *username != "" && *password != "" {
+		return nil
+	}
+	u, err := url.Parse(*baseURL)
+	if err != nil {
+		// main reports the unparseable URL.
+		return nil
+	}
+	path := netrcPath()
+	if path == "" {
+		return nil
+	}
+	entry, found, err := readNetrc(path, u.Hostname())
+	if err != nil || !found {
+		return err
+	}
+	if *username == "" {
+		*username = entry.login
+	}
+	// The password of another user's entry is no use.
+	if *password == "" && *username == entry.login {
+		*password = entry.password
+	}
+	return nil
+}
+
+// credentialSources describes where resolveCredentials looks for the flag, for validation errors.
+func credentialSources(flagName, envName string) string {
+	sources := fmt.Sprintf("-%s, %s", flagName, envName)
+	if flagName == "jira-password" {
+		sources += ", -jira-password-file"
+	}
+	if flagName != "jira-token" {
+		sources += ", ~/.netrc"
+	}
+	return sources
+}
+
+// netrcPath returns the path of the user's .netrc file, which $NETRC overrides, or the empty string if there
+// is no home directory.
+func netrcPath() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// readNetrc returns the entry for host in the .netrc file at path, or the default entry if there is none for host.
+// A missing file has no entries.
+func readNetrc(path, host string) (netrcEntry, bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return netrcEntry{}, false, nil
+	}
+	if err != nil {
+		return netrcEntry{}, false, err
+	}
+
+	var tokens []string
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		for j, field := range fields {
+			if field == "macdef" {
+				// A macro definition runs to the next empty line.
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				fields = fields[:j]
+				break
+			}
+		}
+		tokens = append(tokens, fields...)
+	}
+
+	var entry, fallback netrcEntry
+	var found, foundDefault bool
+	// current is the entry being read, or nil if the tokens belong to an entry of no interest.
+	var current *netrcEntry
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			current = nil
+			if i+1 < len(tokens) {
+				i++
+				if !found && tokens[i] == host {
+					current, found = &entry, true
+				}
+			}
+		case "default":
+			current = nil
+			if !foundDefault {
+				current, foundDefault = &fallback, true
+			}
+		case "login", "password", "account":
+			if i+1 < len(tokens) {
+				i++
+				if current != nil && tokens[i-1] == "login" {
+					current.login = tokens[i]
+				} else if current != nil && tokens[i-1] == "password" {
+					current.password = tokens[i]
+				}
+			}
+		}
+	}
+	if found {
+		return entry, true, nil
+	}
+	return fallback, foundDefault, nil
+}
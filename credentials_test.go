@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadNetrc(t *testing.T) {
+	path := writeTemp(t, `
+machine other.example.com login bob password b0b
+macdef init
+machine jira.example.com login eve password ev3
+
+machine jira.example.com
+	login admin
+	password admin123
+default login anonymous password guest
+`)
+	defer os.Remove(path)
+
+	entry, found, err := readNetrc(path, "jira.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if !found || entry.login != "admin" || entry.password != "admin123" {
+		t.Fatalf("Want admin/admin123 but got %v %+v\n", found, entry)
+	}
+
+	entry, found, _ = readNetrc(path, "unknown.example.com")
+	if !found || entry.login != "anonymous" {
+		t.Fatalf("Want the default entry but got %v %+v\n", found, entry)
+	}
+
+	if _, found, err := readNetrc(path+".missing", "jira.example.com"); found || err != nil {
+		t.Fatalf("Want no entry and no error but got %v %v\n", found, err)
+	}
+}
+
+func TestResolveCredentials(t *testing.T) {
+	defer func(u, p, f, b string) { *username, *password, *passwordFile, *baseURL = u, p, f, b }(*username, *password, *passwordFile, *baseURL)
+
+	secret := writeTemp(t, "s3cret\n")
+	defer os.Remove(secret)
+	netrc := writeTemp(t, "machine jira.example.com login admin password admin123\n")
+	defer os.Remove(netrc)
+	t.Setenv("NETRC", netrc)
+	t.Setenv("KRAKEN_JIRA_USERNAME", "")
+	t.Setenv("KRAKEN_JIRA_PASSWORD", "")
+
+	*username, *password, *passwordFile, *baseURL = "", "", secret, "https://jira.example.com/jira"
+	if err := resolveCredentials(); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if *username != "admin" || *password != "s3cret" {
+		t.Fatalf("Want admin/s3cret but got %s/%s\n", *username, *password)
+	}
+
+	t.Setenv("KRAKEN_JIRA_USERNAME", "ci")
+	*username, *password, *passwordFile = "", "", ""
+	if err := resolveCredentials(); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if *username != "ci" || *password != "" {
+		t.Fatalf("Want ci without the netrc password of admin but got %s/%s\n", *username, *password)
+	}
+}
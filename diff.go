@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xoom/kraken/release"
+)
+
+// The default templates of the changelog formats.  They are executed with changelogData.
+const (
+	markdownChangelogTemplate = `# {{.Component}} changes from {{.From}} to {{.To}}
+{{range .Versions}}
+## {{.Version}}{{if .ReleaseDate}} ({{.ReleaseDate}}){{end}}
+{{range .Groups}}
+### {{.Type}}
+
+{{range .Issues}}- [{{.Key}}]({{issueURL .Key}}) {{.Summary}}
+{{end}}{{else}}
+No resolved issues.
+{{end}}{{else}}
+No versions between {{.From}} and {{.To}}.
+{{end}}`
+
+	textChangelogTemplate = `{{.Component}} changes from {{.From}} to {{.To}}
+{{range .Versions}}
+{{.Version}}{{if .ReleaseDate}}, released {{.ReleaseDate}}{{end}}
+{{range .Groups}}  {{.Type}}:
+{{range .Issues}}    {{.Key}}  {{.Summary}}
+{{end}}{{else}}  No resolved issues.
+{{end}}{{else}}
+No versions between {{.From}} and {{.To}}.
+{{end}}`
+
+	htmlChangelogTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Component}} changes from {{.From}} to {{.To}}</title>
+</head>
+<body>
+<h1>{{.Component}} changes from {{.From}} to {{.To}}</h1>
+{{range .Versions}}<h2>{{.Version}}{{if .ReleaseDate}} ({{.ReleaseDate}}){{end}}</h2>
+{{range .Groups}}<h3>{{.Type}}</h3>
+<ul>
+{{range .Issues}}<li><a href="{{issueURL .Key}}">{{.Key}}</a> {{.Summary}}</li>
+{{end}}</ul>
+{{else}}<p>No resolved issues.</p>
+{{end}}{{else}}<p>No versions between {{.From}} and {{.To}}.</p>
+{{end}}</body>
+</html>
+`
+)
+
+// changelogTemplates are the default changelog templates by format.
+var changelogTemplates = map[string]string{
+	"markdown": markdownChangelogTemplate,
+	"text":     textChangelogTemplate,
+	"html":     htmlChangelogTemplate,
+}
+
+// changelogData is what changelog templates are executed with.
+type changelogData struct {
+	release.Changelog
+	// BaseURL is -jira-base-url.
+	BaseURL string
+}
+
+// renderChangelog writes changelog to w in the given format.  A non-empty templateText replaces the format's
+// default template.
+func renderChangelog(w io.Writer, changelog release.Changelog, format, templateText string) error {
+	data := changelogData{Changelog: changelog, BaseURL: strings.TrimRight(*baseURL, "/")}
+	return render(w, format, templateText, changelogTemplates, data, changelog)
+}
+
+func runDiff(ctx context.Context, releaser *release.Releaser) {
+	resolveComponent()
+	changelog, err := releaser.Diff(ctx, release.DiffRequest{
+		ProjectKey:      *projectKey,
+		ComponentName:   *componentName,
+		FromVersionName: *fromVersionName,
+		ToVersionName:   *toVersionName,
+		Order:           release.Order(*diffOrder),
+	})
+	if err != nil {
+		fail(err, false)
+	}
+	templateText, err := readTemplate()
+	if err != nil {
+		fail(err, false)
+	}
+	err = writeOutput(*notesOutput, "changelog", func(w io.Writer) error {
+		return renderChangelog(w, changelog, *notesFormat, templateText)
+	})
+	if err != nil {
+		fail(err, false)
+	}
+}
+
+// validateDiff validates the flags of the diff command.
+func validateDiff() []error {
+	errors := validateJira()
+	errors = append(errors, validateNotesFormat()...)
+	errors = append(errors, validateComponent()...)
+	if *fromVersionName == "" || *toVersionName == "" {
+		errors = append(errors, fmt.Errorf("from and to must be provided"))
+	} else if *fromVersionName == *toVersionName {
+		errors = append(errors, fmt.Errorf("from and to must be different"))
+	}
+	switch release.Order(*diffOrder) {
+	case release.OrderVersion, release.OrderReleaseDate:
+	default:
+		errors = append(errors, fmt.Errorf("diff-order must be version or release-date"))
+	}
+	if *manifestPath != "" {
+		errors = append(errors, fmt.Errorf("manifest is not supported by diff"))
+	}
+	return errors
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/xoom/kraken/release"
+)
+
+func TestRenderChangelog(t *testing.T) {
+	changelog := release.Changelog{Project: "P", Component: "rest-server", From: "2.1", To: "2.3", Versions: []release.Notes{
+		testNotes,
+		{Project: "P", Component: "rest-server", Version: "2.3"},
+	}}
+
+	var tests = []struct {
+		format string
+		want   []string
+	}{
+		{"markdown", []string{"# rest-server changes from 2.1 to 2.3\n", "## 1.1 (1/Jan/15)\n", "### Bug\n", "## 2.3\n\nNo resolved issues.\n"}},
+		{"text", []string{"1.1, released 1/Jan/15\n  Bug:\n    P-1  Crash <on> start\n", "2.3\n  No resolved issues.\n"}},
+		{"html", []string{"<h2>1.1 (1/Jan/15)</h2>", "<h3>Bug</h3>", "Crash &lt;on&gt; start"}},
+		{"json", []string{`"from": "2.1"`, `"key": "P-1"`}},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := renderChangelog(&b, changelog, test.format, ""); err != nil {
+			t.Fatalf("%s: unexpected error: %v\n", test.format, err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(b.String(), want) {
+				t.Fatalf("%s: want %q in\n%s\n", test.format, want, b.String())
+			}
+		}
+	}
+}
+
+func TestDiffStdout(t *testing.T) {
+	out := runKraken(t, "diff", "-component-name", "rest-server", "-from", "2.1", "-to", "2.2", "-notes-format", "json")
+	var changelog release.Changelog
+	if err := json.Unmarshal(out, &changelog); err != nil {
+		t.Fatalf("Want only the changelog on stdout but got %v:\n%s\n", err, out)
+	}
+	if len(changelog.Versions) != 1 || changelog.Versions[0].Version != "2.2" {
+		t.Fatalf("Unexpected changelog: %+v\n", changelog)
+	}
+}
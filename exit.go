@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/xoom/kraken/jira"
+	"github.com/xoom/kraken/release"
+)
+
+// Process exit codes.  Keep exitCodes in step.
+const (
+	exitFailure           = 1
+	exitValidation        = 2
+	exitUnauthorized      = 3
+	exitProjectNotFound   = 4
+	exitComponentNotFound = 5
+	exitServerError       = 6
+	exitConflict          = 7
+	exitPartialRelease    = 8
+	exitAlreadyReleased   = 9
+	exitUnresolvedIssues  = 10
+	exitNotes             = 11
+)
+
+// exitCodes documents the exit codes in the usage output.
+var exitCodes = []struct {
+	code        int
+	description string
+}{
+	{0, "Success."},
+	{exitFailure, "Failure not covered below."},
+	{exitValidation, "Invalid flags or manifest."},
+	{exitUnauthorized, "Jira rejected the credentials or the user lacks permission."},
+	{exitProjectNotFound, "The project does not exist."},
+	{exitComponentNotFound, "The component does not exist in the project."},
+	{exitServerError, "Jira had an internal error."},
+	{exitConflict, "Jira reported a conflicting change."},
+	{exitPartialRelease, "Jira was changed before the failure, or some manifest components failed.  See undo."},
+	{exitAlreadyReleased, "With -fail-if-released, the version was already released."},
+	{exitUnresolvedIssues, "The version has unresolved issues of the component.  See -allow-unresolved."},
+	{exitNotes, "With -notes, the release succeeded but its release notes could not be written."},
+}
+
+// exitCode returns the process exit code for err.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, release.ErrProjectNotFound):
+		return exitProjectNotFound
+	case errors.Is(err, release.ErrComponentNotFound):
+		return exitComponentNotFound
+	case errors.Is(err, release.ErrAlreadyReleased):
+		return exitAlreadyReleased
+	case errors.Is(err, release.ErrUnresolvedIssues):
+		return exitUnresolvedIssues
+	case jira.IsUnauthorized(err), jira.IsForbidden(err):
+		return exitUnauthorized
+	case jira.IsConflict(err):
+		return exitConflict
+	case jira.IsServerError(err):
+		return exitServerError
+	}
+	return exitFailure
+}
+
+// hint returns advice on fixing the cause of err, or the empty string if there is none.
+func hint(err error) string {
+	switch {
+	case errors.Is(err, release.ErrProjectNotFound):
+		return "Check -project-key and -jira-base-url."
+	case errors.Is(err, release.ErrComponentNotFound):
+		return "Check -component-name or -stashkins-job-name against the project's components."
+	case jira.IsUnauthorized(err):
+		return "Jira rejected the credentials.  Check -jira-auth, -jira-username, -jira-password and -jira-token."
+	case jira.IsForbidden(err):
+		return "Jira refused the request.  Check that the user has administrator permission on the project, and log in through the browser once if Jira asks for a CAPTCHA."
+	case jira.IsNotFound(err):
+		return "Jira could not find what was asked for.  Check -jira-base-url, and that the Component Versions add-on is installed."
+	case jira.IsConflict(err):
+		return "Jira reported a conflict, possibly with a change made at the same time.  Run kraken again."
+	case jira.IsServerError(err):
+		return "Jira had an internal error.  Check that Jira is up and run kraken again."
+	case errors.Is(err, release.ErrUnresolvedIssues):
+		return "Resolve the issues, pass -move-unresolved-to-next to move them to the next version, or pass -allow-unresolved to release anyway."
+	case errors.Is(err, context.DeadlineExceeded):
+		return "kraken ran out of time.  Raise -timeout or check that Jira is responding."
+	case errors.Is(err, context.Canceled):
+		return "kraken was interrupted."
+	}
+	return ""
+}
+
+// fail logs err with any advice on fixing it and exits with the exit code for err.  If Jira was changed before
+// the error, it exits with exitPartialRelease instead.
+func fail(err error, changed bool) {
+	Log.Printf("Error: %v\n", err)
+	if h := hint(err); h != "" {
+		Log.Printf("%s\n", h)
+	}
+	code := exitCode(err)
+	if changed {
+		Log.Printf("Jira was changed before the error.  Run kraken again to finish, or undo the run.\n")
+		code = exitPartialRelease
+	}
+	Log.Printf("Exiting.\n")
+	os.Exit(code)
+}
+
+// failNotes logs err, the failure to write the release notes after a release that succeeded, and exits with
+// exitNotes.  The release stands, so undo is not suggested.
+func failNotes(err error) {
+	Log.Printf("Error writing release notes: %v\n", err)
+	if h := hint(err); h != "" {
+		Log.Printf("%s\n", h)
+	}
+	Log.Printf("The release succeeded.  Run the notes command to write its release notes.\n")
+	Log.Printf("Exiting.\n")
+	os.Exit(exitNotes)
+}
+
+// batchExitCode returns the exit code for a batch with failed components.  If every component failed without
+// changing Jira, that is the exit code of the first failure.
+func batchExitCode(result release.BatchResult, dryRun bool) int {
+	for _, c := range result.Components {
+		if c.Err == nil || (c.Result.Changed() && !dryRun) {
+			return exitPartialRelease
+		}
+	}
+	return exitCode(result.Components[0].Err)
+}
+
+// failValidation logs the validation errors and exits with exitValidation.
+func failValidation(errs ...error) {
+	for _, err := range errs {
+		Log.Printf("Error: %+v\n", err)
+	}
+	Log.Printf("Exiting.\n")
+	os.Exit(exitValidation)
+}
+
+func printExitCodes(w io.Writer) {
+	fmt.Fprintf(w, "Exit codes:\n")
+	for _, c := range exitCodes {
+		fmt.Fprintf(w, "  %2d  %s\n", c.code, c.description)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/xoom/kraken/jira"
+	"github.com/xoom/kraken/release"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("Boom"), exitFailure},
+		{&jira.APIError{StatusCode: 401}, exitUnauthorized},
+		{&jira.APIError{StatusCode: 403}, exitUnauthorized},
+		{fmt.Errorf("%w: P: %w", release.ErrProjectNotFound, &jira.APIError{StatusCode: 404}), exitProjectNotFound},
+		{fmt.Errorf("%w: c", release.ErrComponentNotFound), exitComponentNotFound},
+		{fmt.Errorf("%w: c", release.ErrAlreadyReleased), exitAlreadyReleased},
+		{fmt.Errorf("%w: c", release.ErrUnresolvedIssues), exitUnresolvedIssues},
+		{&jira.APIError{StatusCode: 404}, exitFailure},
+		{&jira.APIError{StatusCode: 409}, exitConflict},
+		{&jira.APIError{StatusCode: 502}, exitServerError},
+		{&jira.APIError{StatusCode: 400}, exitFailure},
+	}
+	for _, test := range tests {
+		if got := exitCode(test.err); got != test.want {
+			t.Fatalf("Want %d for %v but got %d\n", test.want, test.err, got)
+		}
+	}
+}
+
+func TestHint(t *testing.T) {
+	if hint(errors.New("Boom")) != "" {
+		t.Fatalf("Want no hint\n")
+	}
+	if hint(fmt.Errorf("wrapped: %w", context.DeadlineExceeded)) == "" {
+		t.Fatalf("Want a hint\n")
+	}
+	if hint(fmt.Errorf("wrapped: %w", &jira.APIError{StatusCode: 401})) == "" {
+		t.Fatalf("Want a hint\n")
+	}
+}
+
+func TestBatchExitCode(t *testing.T) {
+	missing := fmt.Errorf("%w: c", release.ErrComponentNotFound)
+	allFailed := release.BatchResult{Components: []release.ComponentResult{{Err: missing}, {Err: errors.New("Boom")}}}
+	if got := batchExitCode(allFailed, false); got != exitComponentNotFound {
+		t.Fatalf("Want %d but got %d\n", exitComponentNotFound, got)
+	}
+
+	someFailed := release.BatchResult{Components: []release.ComponentResult{{}, {Err: missing}}}
+	if got := batchExitCode(someFailed, false); got != exitPartialRelease {
+		t.Fatalf("Want %d but got %d\n", exitPartialRelease, got)
+	}
+
+	changedThenFailed := release.BatchResult{Components: []release.ComponentResult{{Err: missing, Result: release.Result{ReleasedFlagChanged: true}}}}
+	if got := batchExitCode(changedThenFailed, false); got != exitPartialRelease {
+		t.Fatalf("Want %d but got %d\n", exitPartialRelease, got)
+	}
+	if got := batchExitCode(changedThenFailed, true); got != exitComponentNotFound {
+		t.Fatalf("Want %d but got %d\n", exitComponentNotFound, got)
+	}
+}
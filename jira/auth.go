@@ -0,0 +1,147 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type (
+	// An Authenticator adds credentials to the client's requests.
+	Authenticator interface {
+		Authenticate(req *http.Request) error
+	}
+
+	// A Refresher is an Authenticator whose credentials expire.  When Jira responds to a request with 401
+	// Unauthorized, the client calls Refresh and sends the request once more.
+	Refresher interface {
+		Authenticator
+		Refresh(ctx context.Context) error
+	}
+
+	// BasicAuth authenticates with a username and password.
+	BasicAuth struct {
+		Username string
+		Password string
+	}
+
+	// CloudToken authenticates with Jira Cloud with the email address of an Atlassian account and an API token of
+	// that account.
+	CloudToken struct {
+		Email string
+		Token string
+	}
+
+	// BearerToken authenticates with a Jira Data Center or Server personal access token.
+	BearerToken struct {
+		Token string
+	}
+
+	// SessionAuth logs in with a username and password and authenticates with the session cookie Jira returns.
+	// It logs in on first use and again when the session expires.  A SessionAuth is safe for concurrent use.
+	SessionAuth struct {
+		baseURL    *url.URL
+		username   string
+		password   string
+		httpClient *http.Client
+
+		mu      sync.Mutex
+		session *http.Cookie
+	}
+)
+
+// WithAuthenticator makes the client authenticate with a instead of the username and password given to NewClient.
+func WithAuthenticator(a Authenticator) Option {
+	return func(client *DefaultClient) {
+		client.auth = a
+	}
+}
+
+func (a BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+func (a CloudToken) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Email, a.Token)
+	return nil
+}
+
+func (a BearerToken) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+// NewSessionAuth returns a SessionAuth that logs in to the Jira at baseURL.
+func NewSessionAuth(baseURL *url.URL, username, password string) *SessionAuth {
+	return &SessionAuth{baseURL: baseURL, username: username, password: password, httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Authenticate adds the session cookie to req, logging in first if there is no session yet.
+func (a *SessionAuth) Authenticate(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.session == nil {
+		if err := a.login(req.Context()); err != nil {
+			return err
+		}
+	}
+	// Replace the cookie of an earlier attempt at req.
+	req.Header.Del("Cookie")
+	req.AddCookie(a.session)
+	return nil
+}
+
+// Refresh logs in again.
+func (a *SessionAuth) Refresh(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.login(ctx)
+}
+
+func (a *SessionAuth) login(ctx context.Context) error {
+	data, err := json.Marshal(map[string]string{"username": a.username, "password": a.password})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/rest/auth/1/session", a.baseURL), bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	response, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err = ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return newAPIError("logging in", req, response.StatusCode, data)
+	}
+
+	var r struct {
+		Session struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"session"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	if r.Session.Name == "" {
+		return fmt.Errorf("error logging in: response has no session")
+	}
+	a.session = &http.Cookie{Name: r.Session.Name, Value: r.Session.Value}
+	return nil
+}
@@ -0,0 +1,148 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestTokenAuthentication(t *testing.T) {
+	var tests = []struct {
+		auth  Authenticator
+		check func(r *http.Request) bool
+	}{
+		{BasicAuth{Username: "admin", Password: "secret"}, func(r *http.Request) bool {
+			u, p, ok := r.BasicAuth()
+			return ok && u == "admin" && p == "secret"
+		}},
+		{CloudToken{Email: "ci@example.com", Token: "api-token"}, func(r *http.Request) bool {
+			u, p, ok := r.BasicAuth()
+			return ok && u == "ci@example.com" && p == "api-token"
+		}},
+		{BearerToken{Token: "pat"}, func(r *http.Request) bool {
+			return r.Header.Get("Authorization") == "Bearer pat"
+		}},
+	}
+	for _, test := range tests {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if !test.check(r) {
+				t.Errorf("%T: unexpected Authorization header %q\n", test.auth, r.Header.Get("Authorization"))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"id": "1"}`))
+		}, DefaultRetryPolicy, WithAuthenticator(test.auth))
+		if _, err := client.GetProject("P"); err != nil {
+			t.Fatalf("%T: unexpected error: %v\n", test.auth, err)
+		}
+	}
+}
+
+// sessionJira is a test Jira that hands out sessions on login and accepts only the latest one.  expire makes it
+// forget the session, as Jira does when a session times out.
+type sessionJira struct {
+	mu       sync.Mutex
+	logins   int
+	requests int
+	session  string
+	// reject makes every request other than login unauthorized.
+	reject bool
+}
+
+func (j *sessionJira) expire() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.session = ""
+}
+
+func (j *sessionJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if r.URL.Path == "/rest/auth/1/session" {
+		var credentials map[string]string
+		json.NewDecoder(r.Body).Decode(&credentials)
+		if r.Method != "POST" || credentials["username"] != "admin" || credentials["password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		j.logins++
+		j.session = fmt.Sprintf("session-%d", j.logins)
+		fmt.Fprintf(w, `{"session": {"name": "JSESSIONID", "value": %q}}`, j.session)
+		return
+	}
+
+	j.requests++
+	cookie, err := r.Cookie("JSESSIONID")
+	if j.reject || err != nil || cookie.Value != j.session || len(r.Cookies()) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Write([]byte(`{"id": "1"}`))
+}
+
+func TestSessionAuth(t *testing.T) {
+	jira := &sessionJira{}
+	baseURL := newTestServer(t, jira.ServeHTTP)
+	client := newClientOf(baseURL, DefaultRetryPolicy, WithAuthenticator(NewSessionAuth(baseURL, "admin", "secret")))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetProject("P"); err != nil {
+			t.Fatalf("Unexpected error: %v\n", err)
+		}
+	}
+	if jira.logins != 1 || jira.requests != 2 {
+		t.Fatalf("Want 1 login and 2 requests but got %d and %d\n", jira.logins, jira.requests)
+	}
+
+	// The session expires: the request is refused, the client logs in again and sends it once more.
+	jira.expire()
+	if _, err := client.GetProject("P"); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if jira.logins != 2 || jira.requests != 4 {
+		t.Fatalf("Want 2 logins and 4 requests but got %d and %d\n", jira.logins, jira.requests)
+	}
+}
+
+func TestSessionAuthRetriesOnce(t *testing.T) {
+	jira := &sessionJira{reject: true}
+	baseURL := newTestServer(t, jira.ServeHTTP)
+	client := newClientOf(baseURL, DefaultRetryPolicy, WithAuthenticator(NewSessionAuth(baseURL, "admin", "secret")))
+
+	if _, err := client.GetProject("P"); !IsUnauthorized(err) {
+		t.Fatalf("Want unauthorized but got %v\n", err)
+	}
+	if jira.logins != 2 || jira.requests != 2 {
+		t.Fatalf("Want 2 logins and 2 requests but got %d and %d\n", jira.logins, jira.requests)
+	}
+}
+
+func TestSessionAuthLoginRefused(t *testing.T) {
+	jira := &sessionJira{}
+	baseURL := newTestServer(t, jira.ServeHTTP)
+	client := newClientOf(baseURL, DefaultRetryPolicy, WithAuthenticator(NewSessionAuth(baseURL, "admin", "wrong")))
+
+	if _, err := client.GetProject("P"); !IsUnauthorized(err) {
+		t.Fatalf("Want unauthorized but got %v\n", err)
+	}
+	if jira.requests != 0 {
+		t.Fatalf("Want no requests without a session but got %d\n", jira.requests)
+	}
+}
+
+func TestBasicAuthNotRefreshed(t *testing.T) {
+	var requests counter
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.add(r)
+		w.WriteHeader(http.StatusUnauthorized)
+	}, DefaultRetryPolicy)
+
+	if _, err := client.GetProject("P"); !IsUnauthorized(err) {
+		t.Fatalf("Want unauthorized but got %v\n", err)
+	}
+	if n := requests.get("GET /rest/api/2/project/P"); n != 1 {
+		t.Fatalf("Want 1 attempt but got %d\n", n)
+	}
+}
@@ -0,0 +1,120 @@
+package jira
+
+import (
+	"context"
+)
+
+type (
+	// CoreContext is Core with methods that take a context.  The context bounds the request, including its retries.
+	CoreContext interface {
+		GetProjectContext(ctx context.Context, projectKey string) (Project, error)
+		GetComponentsContext(ctx context.Context, projectID string) (map[string]Component, error)
+		GetVersionsContext(ctx context.Context, projectID string) (map[string]Version, error)
+		CreateVersionContext(ctx context.Context, projectID, versionName string) (Version, error)
+		DeleteVersionContext(ctx context.Context, versionID string) error
+		GetVersionIssueCountsContext(ctx context.Context, versionID string) (IssueCounts, error)
+		GetApplicationPropertyContext(ctx context.Context, key string) (string, error)
+		UpdateVersionContext(ctx context.Context, versionID string, update VersionUpdate) (Version, error)
+		ReleaseVersionContext(ctx context.Context, versionID, releaseDate string) (Version, error)
+		SearchContext(ctx context.Context, jql string, fields []string) ([]Issue, error)
+		UpdateFixVersionsContext(ctx context.Context, issueKey string, update FixVersionsUpdate) error
+	}
+
+	// ComponentVersionsContext is ComponentVersions with methods that take a context.
+	ComponentVersionsContext interface {
+		GetMappingsContext(ctx context.Context) (map[int]Mapping, error)
+		GetVersionsForComponentContext(ctx context.Context, projectID, componentID string) (map[int]CVVersion, error)
+		UpdateReleaseDateContext(ctx context.Context, mappingID int, releaseDate string) error
+		UpdateReleasedFlagContext(ctx context.Context, mappingID int, released bool) error
+		CreateMappingContext(ctx context.Context, projectID string, componentID string, versionID string) (Mapping, error)
+		DeleteMappingContext(ctx context.Context, mappingID int) error
+	}
+
+	JiraContext interface {
+		CoreContext
+		ComponentVersionsContext
+	}
+
+	boundClient struct {
+		ctx    context.Context
+		client JiraContext
+	}
+)
+
+// WithContext returns a Jira whose methods call the context variants of client's methods with ctx.  If client
+// has no context variants, WithContext returns client, whose methods then ignore ctx.
+func WithContext(ctx context.Context, client Jira) Jira {
+	c, ok := client.(JiraContext)
+	if !ok {
+		return client
+	}
+	return boundClient{ctx: ctx, client: c}
+}
+
+func (b boundClient) GetProject(projectKey string) (Project, error) {
+	return b.client.GetProjectContext(b.ctx, projectKey)
+}
+
+func (b boundClient) GetComponents(projectID string) (map[string]Component, error) {
+	return b.client.GetComponentsContext(b.ctx, projectID)
+}
+
+func (b boundClient) GetVersions(projectID string) (map[string]Version, error) {
+	return b.client.GetVersionsContext(b.ctx, projectID)
+}
+
+func (b boundClient) CreateVersion(projectID, versionName string) (Version, error) {
+	return b.client.CreateVersionContext(b.ctx, projectID, versionName)
+}
+
+func (b boundClient) DeleteVersion(versionID string) error {
+	return b.client.DeleteVersionContext(b.ctx, versionID)
+}
+
+func (b boundClient) GetVersionIssueCounts(versionID string) (IssueCounts, error) {
+	return b.client.GetVersionIssueCountsContext(b.ctx, versionID)
+}
+
+func (b boundClient) UpdateVersion(versionID string, update VersionUpdate) (Version, error) {
+	return b.client.UpdateVersionContext(b.ctx, versionID, update)
+}
+
+func (b boundClient) ReleaseVersion(versionID, releaseDate string) (Version, error) {
+	return b.client.ReleaseVersionContext(b.ctx, versionID, releaseDate)
+}
+
+func (b boundClient) GetApplicationProperty(key string) (string, error) {
+	return b.client.GetApplicationPropertyContext(b.ctx, key)
+}
+
+func (b boundClient) Search(jql string, fields []string) ([]Issue, error) {
+	return b.client.SearchContext(b.ctx, jql, fields)
+}
+
+func (b boundClient) UpdateFixVersions(issueKey string, update FixVersionsUpdate) error {
+	return b.client.UpdateFixVersionsContext(b.ctx, issueKey, update)
+}
+
+func (b boundClient) GetMappings() (map[int]Mapping, error) {
+	return b.client.GetMappingsContext(b.ctx)
+}
+
+func (b boundClient) GetVersionsForComponent(projectID, componentID string) (map[int]CVVersion, error) {
+	return b.client.GetVersionsForComponentContext(b.ctx, projectID, componentID)
+}
+
+func (b boundClient) UpdateReleaseDate(mappingID int, releaseDate string) error {
+	return b.client.UpdateReleaseDateContext(b.ctx, mappingID, releaseDate)
+}
+
+func (b boundClient) UpdateReleasedFlag(mappingID int, released bool) error {
+	return b.client.UpdateReleasedFlagContext(b.ctx, mappingID, released)
+}
+
+func (b boundClient) CreateMapping(projectID, componentID, versionID string) (Mapping, error) {
+	return b.client.CreateMappingContext(b.ctx, projectID, componentID, versionID)
+}
+
+func (b boundClient) DeleteMapping(mappingID int) error {
+	return b.client.DeleteMappingContext(b.ctx, mappingID)
+}
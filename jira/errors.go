@@ -0,0 +1,89 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// APIError is returned when Jira responds with an unexpected status code.  ErrorMessages and Errors are the
+// fields of Jira's error response body, when the body is one.
+type APIError struct {
+	// Op describes what the client was doing, for example "getting project".
+	Op            string
+	Method        string
+	URL           string
+	StatusCode    int
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+	// Body is the raw response body.
+	Body string
+}
+
+func newAPIError(op string, req *http.Request, statusCode int, body []byte) *APIError {
+	e := &APIError{Op: op, Method: req.Method, URL: req.URL.String(), StatusCode: statusCode, Body: string(body)}
+	// Not every error response is Jira's JSON error body; the raw body is kept either way.
+	json.Unmarshal(body, e)
+	return e
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("error %s: %s %s: %d %s", e.Op, e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if details := e.Details(); details != "" {
+		msg += ": " + details
+	}
+	return msg
+}
+
+// Details joins Jira's error messages and field errors, sorted by field name.
+func (e *APIError) Details() string {
+	details := append([]string(nil), e.ErrorMessages...)
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		details = append(details, fmt.Sprintf("%s: %s", field, e.Errors[field]))
+	}
+	return strings.Join(details, "; ")
+}
+
+// StatusCode returns the status code of the APIError in err's chain, or 0 if there is none.
+func StatusCode(err error) int {
+	var e *APIError
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is a Jira 404 Not Found response.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether err is a Jira 401 Unauthorized response, which Jira returns for bad credentials.
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
+
+// IsForbidden reports whether err is a Jira 403 Forbidden response, which Jira returns when the user lacks
+// permission or must solve a CAPTCHA after failed logins.
+func IsForbidden(err error) bool {
+	return StatusCode(err) == http.StatusForbidden
+}
+
+// IsConflict reports whether err is a Jira 409 Conflict response.
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsServerError reports whether err is a Jira 5xx response.
+func IsServerError(err error) bool {
+	code := StatusCode(err)
+	return code >= 500 && code < 600
+}
@@ -0,0 +1,142 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// searchJira serves a search of total issues, at most pageSize per page whatever maxResults asks for, and records
+// the startAt of each request.
+func searchJira(t *testing.T, total, pageSize int, startAts *[]int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.Method != "GET" || r.URL.Path != "/rest/api/2/search" {
+			t.Errorf("Unexpected %s %s\n", r.Method, r.URL)
+		}
+		if query.Get("jql") != "project = 1 AND fixVersion = 10 ORDER BY key" || query.Get("fields") != "summary,status" || query.Get("maxResults") != "100" {
+			t.Errorf("Unexpected query %v\n", query)
+		}
+		startAt, err := strconv.Atoi(query.Get("startAt"))
+		if err != nil {
+			t.Errorf("Unexpected startAt %q\n", query.Get("startAt"))
+		}
+		*startAts = append(*startAts, startAt)
+
+		page := struct {
+			StartAt int     `json:"startAt"`
+			Total   int     `json:"total"`
+			Issues  []Issue `json:"issues"`
+		}{StartAt: startAt, Total: total, Issues: []Issue{}}
+		for i := startAt; i < total && i < startAt+pageSize; i++ {
+			page.Issues = append(page.Issues, Issue{Key: fmt.Sprintf("P-%d", i+1)})
+		}
+		json.NewEncoder(w).Encode(page)
+	}
+}
+
+func TestSearchPages(t *testing.T) {
+	var tests = []struct {
+		total        int
+		pageSize     int
+		wantStartAts []int
+	}{
+		{0, 100, []int{0}},
+		{100, 100, []int{0}},
+		{250, 100, []int{0, 100, 200}},
+		// Jira may return fewer issues than asked for.
+		{120, 50, []int{0, 50, 100}},
+	}
+	for _, test := range tests {
+		var startAts []int
+		client := newTestClient(t, searchJira(t, test.total, test.pageSize, &startAts), DefaultRetryPolicy)
+
+		issues, err := client.Search("project = 1 AND fixVersion = 10 ORDER BY key", []string{"summary", "status"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v\n", err)
+		}
+		if len(issues) != test.total {
+			t.Fatalf("%d issues: want all but got %d\n", test.total, len(issues))
+		}
+		for i, issue := range issues {
+			if want := fmt.Sprintf("P-%d", i+1); issue.Key != want {
+				t.Fatalf("Want %s at %d but got %s\n", want, i, issue.Key)
+			}
+		}
+		if !reflect.DeepEqual(startAts, test.wantStartAts) {
+			t.Fatalf("%d issues in pages of %d: want startAt %v but got %v\n", test.total, test.pageSize, test.wantStartAts, startAts)
+		}
+	}
+}
+
+func TestSearchStopsAtEmptyPage(t *testing.T) {
+	// Jira's total counts issues deleted since the search began.
+	var requests counter
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.add(r) == 1 {
+			w.Write([]byte(`{"startAt": 0, "total": 3, "issues": [{"key": "P-1"}]}`))
+			return
+		}
+		w.Write([]byte(`{"startAt": 1, "total": 3, "issues": []}`))
+	}, DefaultRetryPolicy)
+
+	issues, err := client.Search("project = 1", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(issues) != 1 || requests.get("GET /rest/api/2/search") != 2 {
+		t.Fatalf("Want 1 issue in 2 requests but got %d in %d\n", len(issues), requests.get("GET /rest/api/2/search"))
+	}
+}
+
+func TestSearchError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errorMessages": ["Error in the JQL Query"]}`))
+	}, DefaultRetryPolicy)
+
+	_, err := client.Search("project = ", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Details() != "Error in the JQL Query" {
+		t.Fatalf("Want a 400 APIError but got %v\n", err)
+	}
+}
+
+func TestUpdateFixVersions(t *testing.T) {
+	var body map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/rest/api/2/issue/P-1" || r.Header.Get("Content-type") != "application/json" {
+			t.Errorf("Unexpected %s %s\n", r.Method, r.URL)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Unexpected error: %v\n", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}, DefaultRetryPolicy)
+
+	if err := client.UpdateFixVersions("P-1", FixVersionsUpdate{Add: []string{"11"}, Remove: []string{"10"}}); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	// Only the given versions are added and removed; the issue's other fix versions are left as they are.
+	var want map[string]interface{}
+	json.Unmarshal([]byte(`{"update": {"fixVersions": [{"remove": {"id": "10"}}, {"add": {"id": "11"}}]}}`), &want)
+	if !reflect.DeepEqual(body, want) {
+		t.Fatalf("Want %v but got %v\n", want, body)
+	}
+}
+
+func TestUpdateFixVersionsError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors": {"fixVersions": "Version id '11' is not valid"}}`))
+	}, DefaultRetryPolicy)
+
+	err := client.UpdateFixVersions("P-1", FixVersionsUpdate{Add: []string{"11"}})
+	if StatusCode(err) != http.StatusBadRequest {
+		t.Fatalf("Want a 400 APIError but got %v\n", err)
+	}
+}
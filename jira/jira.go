@@ -0,0 +1,760 @@
+// Package jira is a client of the JIRA REST API with Oguz Component Mappings.  It began as a copy of
+// github.com/xoom/jira at 6354f9e and is maintained here with kraken.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logger is the default logger of clients.  It writes to stderr, leaving stdout to the program using the client.
+var logger = log.New(os.Stderr, "", log.Ldate|log.Ltime|log.Lshortfile)
+
+type (
+	Jira interface {
+		Core
+		ComponentVersions
+	}
+
+	// https://docs.atlassian.com/jira/REST/latest/
+	Core interface {
+		GetProject(projectKey string) (Project, error)
+		GetComponents(projectID string) (map[string]Component, error)
+		GetVersions(projectID string) (map[string]Version, error)
+		CreateVersion(projectID, versionName string) (Version, error)
+		DeleteVersion(versionID string) error
+		GetVersionIssueCounts(versionID string) (IssueCounts, error)
+		GetApplicationProperty(key string) (string, error)
+		UpdateVersion(versionID string, update VersionUpdate) (Version, error)
+		ReleaseVersion(versionID, releaseDate string) (Version, error)
+		Search(jql string, fields []string) ([]Issue, error)
+		UpdateFixVersions(issueKey string, update FixVersionsUpdate) error
+	}
+
+	// http://jiraplugins.denizoguz.com/wp-content/uploads/2014/09/REST-Manual-v0.1.pdf
+	ComponentVersions interface {
+		GetMappings() (map[int]Mapping, error)
+		GetVersionsForComponent(projectID, componentID string) (map[int]CVVersion, error)
+		UpdateReleaseDate(mappingID int, releaseDate string) error
+		UpdateReleasedFlag(mappingID int, released bool) error
+		CreateMapping(projectID string, componentID string, versionID string) (Mapping, error)
+		DeleteMapping(mappingID int) error
+	}
+
+	Project struct {
+		ID string `json:"id"`
+	}
+
+	DefaultClient struct {
+		auth       Authenticator
+		baseURL    *url.URL
+		httpClient *http.Client
+		retry      RetryPolicy
+		logger     *log.Logger
+		Jira
+	}
+
+	Component struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	Version struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Project     string `json:"project"`
+		ProjectID   int    `json:"projectId"`
+		Archived    bool   `json:"archived"`
+		Released    bool   `json:"released"`
+		ReleaseDate string `json:"releaseDate,omitempty"`
+	}
+
+	// The fields of a version UpdateVersion changes.  Nil fields are left as they are.  ReleaseDate is yyyy-MM-dd.
+	VersionUpdate struct {
+		Released    *bool   `json:"released,omitempty"`
+		ReleaseDate *string `json:"releaseDate,omitempty"`
+		Archived    *bool   `json:"archived,omitempty"`
+	}
+
+	// The number of issues that have a version as their fix version or affects version.
+	IssueCounts struct {
+		IssuesFixedCount    int `json:"issuesFixedCount"`
+		IssuesAffectedCount int `json:"issuesAffectedCount"`
+	}
+
+	ApplicationProperty struct {
+		ID    string `json:"id"`
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+
+	// The fix versions UpdateFixVersions adds to and removes from an issue, by version ID.  The issue's other fix
+	// versions are left as they are.
+	FixVersionsUpdate struct {
+		Add    []string
+		Remove []string
+	}
+
+	// An issue found by Search.  Fields holds only the fields asked for.
+	Issue struct {
+		ID     string      `json:"id"`
+		Key    string      `json:"key"`
+		Fields IssueFields `json:"fields"`
+	}
+
+	IssueFields struct {
+		Summary     string      `json:"summary,omitempty"`
+		IssueType   *IssueType  `json:"issuetype,omitempty"`
+		Status      *Status     `json:"status,omitempty"`
+		Resolution  *Resolution `json:"resolution,omitempty"`
+		FixVersions []Version   `json:"fixVersions,omitempty"`
+		Components  []Component `json:"components,omitempty"`
+	}
+
+	IssueType struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	Status struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	Resolution struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	// Component Version add-on's notion of a version
+	CVVersion struct {
+		ID          int    `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Released    bool   `json:"isReleased"`
+	}
+
+	Mapping struct {
+		ID             int    `json:"id"`
+		ProjectID      int    `json:"projectId"`
+		ProjectKey     string `json:"projectKey"`
+		ProjectName    string `json:"projectName"`
+		ComponentID    int    `json:"componentId"`
+		VersionID      int    `json:"versionId"`
+		VersionName    string `json:"versionName"`
+		ComponentName  string `json:"componentName"`
+		Released       bool   `json:"released"`
+		ReleaseDateStr string `json:"releaseDateStr"`
+	}
+
+	nopLogger struct {
+		io.WriteCloser
+	}
+)
+
+// NewClient returns a new default Jira client for the given Jira admin username/password and base REST URL.
+// WithAuthenticator replaces the username and password with other credentials.
+func NewClient(username, password string, baseURL *url.URL, options ...Option) Jira {
+	client := DefaultClient{auth: BasicAuth{Username: username, Password: password}, baseURL: baseURL, httpClient: &http.Client{Timeout: 10 * time.Second}, retry: DefaultRetryPolicy, logger: logger}
+	for _, option := range options {
+		option(&client)
+	}
+	return client
+}
+
+// GetProject calls GetProjectContext with the background context.
+func (client DefaultClient) GetProject(projectKey string) (Project, error) {
+	return client.GetProjectContext(context.Background(), projectKey)
+}
+
+// GetProjectContext returns a representation of a Jira project for the given project key.  An example of a key is MYPROJ.
+func (client DefaultClient) GetProjectContext(ctx context.Context, projectKey string) (Project, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/api/2/project/%s", client.baseURL, projectKey), nil)
+	if err != nil {
+		return Project{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return Project{}, err
+	}
+	if responseCode != http.StatusOK {
+		return Project{}, newAPIError("getting project", req, responseCode, data)
+	}
+
+	var r Project
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Project{}, err
+	}
+
+	return r, nil
+}
+
+// GetComponents calls GetComponentsContext with the background context.
+func (client DefaultClient) GetComponents(projectID string) (map[string]Component, error) {
+	return client.GetComponentsContext(context.Background(), projectID)
+}
+
+// GetComponentsContext returns a map of Component indexed by component name for the given project ID.
+func (client DefaultClient) GetComponentsContext(ctx context.Context, projectID string) (map[string]Component, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/api/2/project/%s/components", client.baseURL, projectID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	if responseCode != http.StatusOK {
+		return nil, newAPIError("getting project components", req, responseCode, data)
+	}
+
+	var r []Component
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]Component)
+	for _, c := range r {
+		m[c.Name] = c
+	}
+
+	return m, nil
+}
+
+// GetVersions calls GetVersionsContext with the background context.
+func (client DefaultClient) GetVersions(projectID string) (map[string]Version, error) {
+	return client.GetVersionsContext(context.Background(), projectID)
+}
+
+// GetVersionsContext returns a map of Version indexed by version name for the given project ID.
+func (client DefaultClient) GetVersionsContext(ctx context.Context, projectID string) (map[string]Version, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/api/2/project/%s/versions", client.baseURL, projectID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	if responseCode != http.StatusOK {
+		return nil, newAPIError("getting project versions", req, responseCode, data)
+	}
+
+	var r []Version
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]Version)
+	for _, c := range r {
+		m[c.Name] = c
+	}
+
+	return m, nil
+}
+
+// CreateVersion calls CreateVersionContext with the background context.
+func (client DefaultClient) CreateVersion(projectID, versionName string) (Version, error) {
+	return client.CreateVersionContext(context.Background(), projectID, versionName)
+}
+
+// CreateVersionContext creates a new version in Jira for the given project ID and version name.
+func (client DefaultClient) CreateVersionContext(ctx context.Context, projectID, versionName string) (Version, error) {
+	i, err := strconv.Atoi(projectID)
+	if err != nil {
+		return Version{}, err
+	}
+
+	data, err := json.Marshal(&Version{Name: versionName, Description: "Version " + versionName, ProjectID: i, Archived: false, Released: false})
+	if err != nil {
+		return Version{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/rest/api/2/version", client.baseURL), bytes.NewBuffer(data))
+	if err != nil {
+		return Version{}, err
+	}
+	req.Header.Set("Content-type", "application/json")
+
+	var existing Version
+	response, data, err := client.send(req, func() (bool, error) {
+		versions, err := client.GetVersionsContext(ctx, projectID)
+		if err != nil {
+			return false, err
+		}
+		v, present := versions[versionName]
+		existing = v
+		return present, nil
+	})
+	if err != nil {
+		return Version{}, err
+	}
+	if response == nil {
+		return existing, nil
+	}
+	if response.StatusCode != http.StatusCreated {
+		return Version{}, newAPIError("creating project version", req, response.StatusCode, data)
+	}
+
+	var v Version
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// UpdateVersion calls UpdateVersionContext with the background context.
+func (client DefaultClient) UpdateVersion(versionID string, update VersionUpdate) (Version, error) {
+	return client.UpdateVersionContext(context.Background(), versionID, update)
+}
+
+// UpdateVersionContext changes the released flag, release date or archived flag of the version for the given version ID.
+func (client DefaultClient) UpdateVersionContext(ctx context.Context, versionID string, update VersionUpdate) (Version, error) {
+	data, err := json.Marshal(&update)
+	if err != nil {
+		return Version{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "PUT", fmt.Sprintf("%s/rest/api/2/version/%s", client.baseURL, versionID), bytes.NewBuffer(data))
+	if err != nil {
+		return Version{}, err
+	}
+	req.Header.Set("Content-type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return Version{}, err
+	}
+	if responseCode != http.StatusOK {
+		return Version{}, newAPIError("updating project version", req, responseCode, data)
+	}
+
+	var v Version
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// ReleaseVersion calls ReleaseVersionContext with the background context.
+func (client DefaultClient) ReleaseVersion(versionID, releaseDate string) (Version, error) {
+	return client.ReleaseVersionContext(context.Background(), versionID, releaseDate)
+}
+
+// ReleaseVersionContext marks the version for the given version ID released on releaseDate, which is yyyy-MM-dd.
+func (client DefaultClient) ReleaseVersionContext(ctx context.Context, versionID, releaseDate string) (Version, error) {
+	released := true
+	return client.UpdateVersionContext(ctx, versionID, VersionUpdate{Released: &released, ReleaseDate: &releaseDate})
+}
+
+// DeleteVersion calls DeleteVersionContext with the background context.
+func (client DefaultClient) DeleteVersion(versionID string) error {
+	return client.DeleteVersionContext(context.Background(), versionID)
+}
+
+// DeleteVersionContext deletes the version for the given version ID.
+func (client DefaultClient) DeleteVersionContext(ctx context.Context, versionID string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("%s/rest/api/2/version/%s", client.baseURL, versionID), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+	if responseCode != http.StatusNoContent {
+		return newAPIError("deleting project version", req, responseCode, data)
+	}
+	return nil
+}
+
+// GetVersionIssueCounts calls GetVersionIssueCountsContext with the background context.
+func (client DefaultClient) GetVersionIssueCounts(versionID string) (IssueCounts, error) {
+	return client.GetVersionIssueCountsContext(context.Background(), versionID)
+}
+
+// GetVersionIssueCountsContext returns the number of issues that have the given version ID as fix version or affects version.
+func (client DefaultClient) GetVersionIssueCountsContext(ctx context.Context, versionID string) (IssueCounts, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/api/2/version/%s/relatedIssueCounts", client.baseURL, versionID), nil)
+	if err != nil {
+		return IssueCounts{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return IssueCounts{}, err
+	}
+	if responseCode != http.StatusOK {
+		return IssueCounts{}, newAPIError("getting version issue counts", req, responseCode, data)
+	}
+
+	var r IssueCounts
+	if err := json.Unmarshal(data, &r); err != nil {
+		return IssueCounts{}, err
+	}
+	return r, nil
+}
+
+// GetApplicationProperty calls GetApplicationPropertyContext with the background context.
+func (client DefaultClient) GetApplicationProperty(key string) (string, error) {
+	return client.GetApplicationPropertyContext(context.Background(), key)
+}
+
+// GetApplicationPropertyContext returns the value of the Jira application property with the given key, for example
+// jira.date.picker.java.format.  Reading application properties requires administrator permission.
+func (client DefaultClient) GetApplicationPropertyContext(ctx context.Context, key string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/api/2/application-properties?key=%s", client.baseURL, url.QueryEscape(key)), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return "", err
+	}
+	if responseCode != http.StatusOK {
+		return "", newAPIError("getting application property", req, responseCode, data)
+	}
+
+	// Jira Server returns the property, Jira Cloud a list holding it.
+	var r ApplicationProperty
+	if err := json.Unmarshal(data, &r); err != nil {
+		var list []ApplicationProperty
+		if err := json.Unmarshal(data, &list); err != nil {
+			return "", err
+		}
+		for _, p := range list {
+			if p.Key == key {
+				return p.Value, nil
+			}
+		}
+		return "", fmt.Errorf("application property %s not found", key)
+	}
+	return r.Value, nil
+}
+
+// searchPageSize is the number of issues Search asks for per page.  Jira may return fewer.
+const searchPageSize = 100
+
+// Search calls SearchContext with the background context.
+func (client DefaultClient) Search(jql string, fields []string) ([]Issue, error) {
+	return client.SearchContext(context.Background(), jql, fields)
+}
+
+// SearchContext returns all issues matching the given JQL query, with the given fields, reading as many pages of
+// results as there are.  No fields means Jira's default fields.
+func (client DefaultClient) SearchContext(ctx context.Context, jql string, fields []string) ([]Issue, error) {
+	var issues []Issue
+	for {
+		query := url.Values{}
+		query.Set("jql", jql)
+		query.Set("startAt", strconv.Itoa(len(issues)))
+		query.Set("maxResults", strconv.Itoa(searchPageSize))
+		if len(fields) != 0 {
+			query.Set("fields", strings.Join(fields, ","))
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/api/2/search?%s", client.baseURL, query.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		responseCode, data, err := client.consumeResponse(req)
+		if err != nil {
+			return nil, err
+		}
+		if responseCode != http.StatusOK {
+			return nil, newAPIError("searching issues", req, responseCode, data)
+		}
+
+		var r struct {
+			StartAt int     `json:"startAt"`
+			Total   int     `json:"total"`
+			Issues  []Issue `json:"issues"`
+		}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, err
+		}
+		issues = append(issues, r.Issues...)
+		// An empty page ends the search even if Jira's total says otherwise, for example when issues were
+		// deleted meanwhile.
+		if len(r.Issues) == 0 || len(issues) >= r.Total {
+			return issues, nil
+		}
+	}
+}
+
+// UpdateFixVersions calls UpdateFixVersionsContext with the background context.
+func (client DefaultClient) UpdateFixVersions(issueKey string, update FixVersionsUpdate) error {
+	return client.UpdateFixVersionsContext(context.Background(), issueKey, update)
+}
+
+// UpdateFixVersionsContext adds fix versions to and removes fix versions from the issue with the given key, keeping
+// its other fix versions.
+func (client DefaultClient) UpdateFixVersionsContext(ctx context.Context, issueKey string, update FixVersionsUpdate) error {
+	type id struct {
+		ID string `json:"id"`
+	}
+	type operation struct {
+		Add    *id `json:"add,omitempty"`
+		Remove *id `json:"remove,omitempty"`
+	}
+	var operations []operation
+	for _, versionID := range update.Remove {
+		operations = append(operations, operation{Remove: &id{versionID}})
+	}
+	for _, versionID := range update.Add {
+		operations = append(operations, operation{Add: &id{versionID}})
+	}
+	data, err := json.Marshal(map[string]map[string][]operation{"update": {"fixVersions": operations}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", fmt.Sprintf("%s/rest/api/2/issue/%s", client.baseURL, url.PathEscape(issueKey)), bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+	if responseCode != http.StatusNoContent {
+		return newAPIError("updating issue fix versions", req, responseCode, data)
+	}
+	return nil
+}
+
+// CreateMapping calls CreateMappingContext with the background context.
+func (client DefaultClient) CreateMapping(projectID, componentID, versionID string) (Mapping, error) {
+	return client.CreateMappingContext(context.Background(), projectID, componentID, versionID)
+}
+
+// CreateMappingContext creates a mapping between the given component ID and version ID in the context of the given project ID.
+func (client DefaultClient) CreateMappingContext(ctx context.Context, projectID, componentID, versionID string) (Mapping, error) {
+	pId, err := strconv.Atoi(projectID)
+	if err != nil {
+		return Mapping{}, err
+	}
+	cId, err := strconv.Atoi(componentID)
+	if err != nil {
+		return Mapping{}, err
+	}
+	vId, err := strconv.Atoi(versionID)
+	if err != nil {
+		return Mapping{}, err
+	}
+
+	data, err := json.Marshal(&Mapping{ProjectID: pId, ComponentID: cId, VersionID: vId, Released: false})
+	if err != nil {
+		return Mapping{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/rest/com.deniz.jira.mapping/latest/", client.baseURL), bytes.NewBuffer(data))
+	if err != nil {
+		return Mapping{}, err
+	}
+	req.Header.Set("Content-type", "application/json")
+
+	var existing Mapping
+	response, data, err := client.send(req, func() (bool, error) {
+		mappings, err := client.GetMappingsContext(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, m := range mappings {
+			if m.ProjectID == pId && m.ComponentID == cId && m.VersionID == vId {
+				existing = m
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return Mapping{}, err
+	}
+	if response == nil {
+		return existing, nil
+	}
+
+	if response.StatusCode != http.StatusCreated {
+		return Mapping{}, newAPIError("creating mapped version", req, response.StatusCode, data)
+	}
+
+	if location, err := response.Location(); err != nil {
+		log.Printf("create-mapping response has no Location header.  Unable to acquire created mapping ID\n")
+		return Mapping{}, nil
+	} else {
+		h := location.String()
+		id, err := strconv.Atoi(h[strings.LastIndex(h, "/")+1:])
+		if err != nil {
+			log.Printf("create-mapping response Location header %s has unparseable non-integer urlPrefix/N mapping ID\n", h)
+			return Mapping{}, nil
+		}
+		return Mapping{ID: id}, nil
+	}
+
+}
+
+// GetMappings calls GetMappingsContext with the background context.
+func (client DefaultClient) GetMappings() (map[int]Mapping, error) {
+	return client.GetMappingsContext(context.Background())
+}
+
+// GetMappingsContext returns all known mappings for all projects.
+func (client DefaultClient) GetMappingsContext(ctx context.Context) (map[int]Mapping, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/com.deniz.jira.mapping/latest/mappings", client.baseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	if responseCode != http.StatusOK {
+		return nil, newAPIError("getting mappings", req, responseCode, data)
+	}
+
+	var r []Mapping
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	m := make(map[int]Mapping)
+	for _, c := range r {
+		m[c.ID] = c
+	}
+	return m, nil
+}
+
+// GetVersionsForComponent calls GetVersionsForComponentContext with the background context.
+func (client DefaultClient) GetVersionsForComponent(projectID, componentID string) (map[int]CVVersion, error) {
+	return client.GetVersionsForComponentContext(context.Background(), projectID, componentID)
+}
+
+// GetVersionsForComponentContext returns the versions for the given component ID in the context of the given project ID.
+func (client DefaultClient) GetVersionsForComponentContext(ctx context.Context, projectID, componentID string) (map[int]CVVersion, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/rest/com.deniz.jira.mapping/latest/applicable_versions?projectId=%s&selectedComponentIds=%s", client.baseURL, projectID, componentID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	if responseCode != http.StatusOK {
+		return nil, newAPIError("getting component versions", req, responseCode, data)
+	}
+
+	var r []CVVersion
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	m := make(map[int]CVVersion)
+	for _, c := range r {
+		m[c.ID] = c
+	}
+	return m, nil
+}
+
+// UpdateReleaseDate calls UpdateReleaseDateContext with the background context.
+func (client DefaultClient) UpdateReleaseDate(mappingID int, releaseDate string) error {
+	return client.UpdateReleaseDateContext(context.Background(), mappingID, releaseDate)
+}
+
+// UpdateReleaseDateContext updates the version release date to releaseDate for the given mapping ID.
+func (client DefaultClient) UpdateReleaseDateContext(ctx context.Context, mappingID int, releaseDate string) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", fmt.Sprintf("%s/rest/com.deniz.jira.mapping/latest/releaseDate/%d?releaseDate=%s", client.baseURL, mappingID, url.QueryEscape(releaseDate)), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+	if responseCode != http.StatusOK {
+		return newAPIError("updating mapping release date", req, responseCode, data)
+	}
+	return nil
+}
+
+// UpdateReleasedFlag calls UpdateReleasedFlagContext with the background context.
+func (client DefaultClient) UpdateReleasedFlag(mappingID int, released bool) error {
+	return client.UpdateReleasedFlagContext(context.Background(), mappingID, released)
+}
+
+// UpdateReleasedFlagContext updates the version released flag for the given mapping ID.
+func (client DefaultClient) UpdateReleasedFlagContext(ctx context.Context, mappingID int, released bool) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", fmt.Sprintf("%s/rest/com.deniz.jira.mapping/latest/releaseFlag/%d?isReleased=%v", client.baseURL, mappingID, released), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+	if responseCode != http.StatusOK {
+		return newAPIError("updating mapping is-released flag", req, responseCode, data)
+	}
+	return nil
+}
+
+// DeleteMapping calls DeleteMappingContext with the background context.
+func (client DefaultClient) DeleteMapping(mappingID int) error {
+	return client.DeleteMappingContext(context.Background(), mappingID)
+}
+
+// DeleteMappingContext deletes the mapping for the given mapping ID.
+func (client DefaultClient) DeleteMappingContext(ctx context.Context, mappingID int) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("%s/rest/com.deniz.jira.mapping/latest/%d", client.baseURL, mappingID), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	responseCode, data, err := client.consumeResponse(req)
+	if err != nil {
+		return err
+	}
+	if responseCode != http.StatusNoContent {
+		return newAPIError("deleting mapping", req, responseCode, data)
+	}
+	return nil
+}
+
+func (client DefaultClient) consumeResponse(req *http.Request) (rc int, buffer []byte, err error) {
+	response, data, err := client.send(req, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	return response.StatusCode, data, nil
+}
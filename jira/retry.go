@@ -0,0 +1,206 @@
+package jira
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy says how often and how patiently the client retries a request that failed with a network error,
+// 429 Too Many Requests or a 5xx status code.  GETs and PUTs are retried as is.  POSTs are retried only after
+// checking that the failed attempt did not create what it was creating.  DELETEs are not retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts made, including the first one.  Values below 1 mean 1.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry.  It doubles with each further retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts, including one asked for by a Retry-After header.
+	MaxDelay time.Duration
+	// Jitter is the fraction, between 0 and 1, by which each delay is randomly shortened.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is the retry policy of clients made without WithRetryPolicy.  It makes a single attempt.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 1}
+
+// An Option configures a client made by NewClient.
+type Option func(*DefaultClient)
+
+// WithRetryPolicy makes the client retry failed requests according to policy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(client *DefaultClient) {
+		client.retry = policy
+	}
+}
+
+// WithLogger makes the client log its retries to l instead of stderr.
+func WithLogger(l *log.Logger) Option {
+	return func(client *DefaultClient) {
+		client.logger = l
+	}
+}
+
+// delay returns how long to wait before the given retry, counting from 1.  retryAfter is the delay the server
+// asked for, or zero.
+func (p RetryPolicy) delay(retry int, retryAfter time.Duration) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(p.Jitter * rand.Float64() * float64(d))
+	}
+	if retryAfter > d {
+		d = retryAfter
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// retryable reports whether a response with the given status code is worth retrying.
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// retryAfter returns the delay asked for by the response's Retry-After header, which is either a number of
+// seconds or an HTTP date, or zero if there is none.
+func retryAfter(response *http.Response) time.Duration {
+	h := response.Header.Get("Retry-After")
+	if h == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(h); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// send sends req and returns the response with its body read.  Failed attempts are retried according to the
+// client's retry policy until the request's context is done.  Before retrying a POST, send calls exists, which
+// reports whether the failed attempt created what the POST creates after all; if it did, send stops and returns
+// a nil response and error.  POSTs are not retried when exists is nil.
+func (client DefaultClient) send(req *http.Request, exists func() (bool, error)) (*http.Response, []byte, error) {
+	idempotent := req.Method == "GET" || req.Method == "PUT"
+	canRetry := idempotent || (req.Method == "POST" && exists != nil)
+
+	attempts := client.retry.MaxAttempts
+	if attempts < 1 || !canRetry {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			if err := rewind(req); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		response, data, err := client.attempt(req)
+		if attempt == attempts || req.Context().Err() != nil {
+			return response, data, err
+		}
+		var wait time.Duration
+		if err == nil {
+			if !retryable(response.StatusCode) {
+				return response, data, nil
+			}
+			wait = retryAfter(response)
+		}
+
+		delay := client.retry.delay(attempt, wait)
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = response.Status
+		}
+		client.logger.Printf("%s %s failed with %s, retrying in %v (attempt %d of %d)\n", req.Method, req.URL, reason, delay, attempt+1, attempts)
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, nil, err
+		}
+
+		if !idempotent {
+			found, err := exists()
+			if err != nil {
+				return nil, nil, err
+			}
+			if found {
+				client.logger.Printf("%s %s succeeded after all, not retrying it\n", req.Method, req.URL)
+				return nil, nil, nil
+			}
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.  It returns ctx's error in the latter case.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// attempt sends req once, and once more if Jira responds 401 Unauthorized and the client's credentials can be
+// refreshed.
+func (client DefaultClient) attempt(req *http.Request) (*http.Response, []byte, error) {
+	response, data, err := client.roundTrip(req)
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, data, err
+	}
+	refresher, ok := client.auth.(Refresher)
+	if !ok {
+		return response, data, nil
+	}
+
+	client.logger.Printf("%s %s was not authorized, logging in again\n", req.Method, req.URL)
+	if err := refresher.Refresh(req.Context()); err != nil {
+		return nil, nil, err
+	}
+	if err := rewind(req); err != nil {
+		return nil, nil, err
+	}
+	return client.roundTrip(req)
+}
+
+// rewind resets the body of req so that it can be sent again.
+func rewind(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+func (client DefaultClient) roundTrip(req *http.Request) (*http.Response, []byte, error) {
+	if err := client.auth.Authenticate(req); err != nil {
+		return nil, nil, err
+	}
+	response, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return response, data, nil
+}
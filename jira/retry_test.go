@@ -0,0 +1,318 @@
+package jira
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPolicy retries quickly.
+var testPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+// newTestServer starts a test server running handler and returns its URL.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *url.URL {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	baseURL, _ := url.Parse(server.URL)
+	return baseURL
+}
+
+// newTestClient returns a client of a test server running handler, with the given retry policy and without logs.
+func newTestClient(t *testing.T, handler http.HandlerFunc, policy RetryPolicy, options ...Option) DefaultClient {
+	return newClientOf(newTestServer(t, handler), policy, options...)
+}
+
+// newClientOf returns a client of the Jira at baseURL, with the given retry policy and without logs.
+func newClientOf(baseURL *url.URL, policy RetryPolicy, options ...Option) DefaultClient {
+	options = append([]Option{WithRetryPolicy(policy), WithLogger(log.New(ioutil.Discard, "", 0))}, options...)
+	return NewClient("u", "p", baseURL, options...).(DefaultClient)
+}
+
+// counter counts requests by method and path.
+type counter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *counter) add(r *http.Request) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[r.Method+" "+r.URL.Path]++
+	return c.counts[r.Method+" "+r.URL.Path]
+}
+
+func (c *counter) get(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[key]
+}
+
+func TestDefaultLoggerIsStderr(t *testing.T) {
+	client := NewClient("u", "p", &url.URL{}).(DefaultClient)
+	if w := client.logger.Writer(); w != os.Stderr {
+		t.Fatalf("Want logs on stderr but got %v\n", w)
+	}
+}
+
+func TestRetryServerErrorsAndTooManyRequests(t *testing.T) {
+	var requests counter
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch requests.add(r) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"id": "1"}`))
+		}
+	}, testPolicy)
+
+	project, err := client.GetProject("P")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if project.ID != "1" {
+		t.Fatalf("Want project 1 but got %+v\n", project)
+	}
+	if n := requests.get("GET /rest/api/2/project/P"); n != 3 {
+		t.Fatalf("Want 3 attempts but got %d\n", n)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var requests counter
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.add(r)
+		w.WriteHeader(http.StatusInternalServerError)
+	}, testPolicy)
+
+	_, err := client.GetProject("P")
+	if !IsServerError(err) {
+		t.Fatalf("Want a server error but got %v\n", err)
+	}
+	if n := requests.get("GET /rest/api/2/project/P"); n != 3 {
+		t.Fatalf("Want 3 attempts but got %d\n", n)
+	}
+}
+
+func TestNoRetryOfClientErrors(t *testing.T) {
+	var requests counter
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.add(r)
+		w.WriteHeader(http.StatusNotFound)
+	}, testPolicy)
+
+	if _, err := client.GetProject("P"); !IsNotFound(err) {
+		t.Fatalf("Want not found but got %v\n", err)
+	}
+	if n := requests.get("GET /rest/api/2/project/P"); n != 1 {
+		t.Fatalf("Want 1 attempt but got %d\n", n)
+	}
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	var requests counter
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.add(r) == 1 {
+			// Drop the connection without a response.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("Unexpected error: %v\n", err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"id": "1"}`))
+	}, testPolicy)
+
+	if _, err := client.GetProject("P"); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if n := requests.get("GET /rest/api/2/project/P"); n != 2 {
+		t.Fatalf("Want 2 attempts but got %d\n", n)
+	}
+}
+
+func TestNoRetryOfDelete(t *testing.T) {
+	var requests counter
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.add(r)
+		w.WriteHeader(http.StatusBadGateway)
+	}, testPolicy)
+
+	if err := client.DeleteVersion("10"); !IsServerError(err) {
+		t.Fatalf("Want a server error but got %v\n", err)
+	}
+	if n := requests.get("DELETE /rest/api/2/version/10"); n != 1 {
+		t.Fatalf("Want 1 attempt but got %d\n", n)
+	}
+}
+
+func TestRetryAfterIsCapped(t *testing.T) {
+	var requests counter
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.add(r) == 1 {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"id": "1"}`))
+	}, testPolicy)
+
+	start := time.Now()
+	if _, err := client.GetProject("P"); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Want Retry-After capped at MaxDelay but waited %v\n", elapsed)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	var tests = []struct {
+		retry      int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{1, 0, 100 * time.Millisecond},
+		{2, 0, 200 * time.Millisecond},
+		{3, 0, 400 * time.Millisecond},
+		{5, 0, time.Second},
+		{1, 500 * time.Millisecond, 500 * time.Millisecond},
+		{1, time.Minute, time.Second},
+	}
+	for _, test := range tests {
+		if got := policy.delay(test.retry, test.retryAfter); got != test.want {
+			t.Fatalf("Retry %d after %v: want %v but got %v\n", test.retry, test.retryAfter, test.want, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.delay(1, 0); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("Want a delay between 50ms and 100ms but got %v\n", got)
+		}
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	var tests = []struct {
+		header string
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 50 * time.Second, time.Minute},
+	}
+	for _, test := range tests {
+		response := &http.Response{Header: http.Header{}}
+		if test.header != "" {
+			response.Header.Set("Retry-After", test.header)
+		}
+		if got := retryAfter(response); got < test.min || got > test.max {
+			t.Fatalf("%q: want between %v and %v but got %v\n", test.header, test.min, test.max, got)
+		}
+	}
+}
+
+func TestCreateVersionNotSentTwice(t *testing.T) {
+	var requests counter
+	var created []Version
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.add(r)
+		switch r.Method + " " + r.URL.Path {
+		case "POST /rest/api/2/version":
+			// Jira creates the version but the response is lost.
+			created = append(created, Version{ID: "10", Name: "1.2", ProjectID: 1})
+			w.WriteHeader(http.StatusBadGateway)
+		case "GET /rest/api/2/project/1/versions":
+			json.NewEncoder(w).Encode(created)
+		default:
+			t.Errorf("Unexpected %s %s\n", r.Method, r.URL)
+		}
+	}, testPolicy)
+
+	v, err := client.CreateVersion("1", "1.2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if v.ID != "10" {
+		t.Fatalf("Want the created version 10 but got %+v\n", v)
+	}
+	if n := requests.get("POST /rest/api/2/version"); n != 1 {
+		t.Fatalf("Want 1 POST but got %d\n", n)
+	}
+}
+
+func TestCreateVersionRetried(t *testing.T) {
+	var requests counter
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /rest/api/2/version":
+			if requests.add(r) == 1 {
+				// Jira fails without creating the version.
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "10", "name": "1.2"}`))
+		case "GET /rest/api/2/project/1/versions":
+			w.Write([]byte(`[]`))
+		default:
+			t.Errorf("Unexpected %s %s\n", r.Method, r.URL)
+		}
+	}, testPolicy)
+
+	v, err := client.CreateVersion("1", "1.2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if v.ID != "10" {
+		t.Fatalf("Want version 10 but got %+v\n", v)
+	}
+	if n := requests.get("POST /rest/api/2/version"); n != 2 {
+		t.Fatalf("Want 2 POSTs but got %d\n", n)
+	}
+}
+
+func TestCreateMappingNotSentTwice(t *testing.T) {
+	var requests counter
+	var created []Mapping
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.add(r)
+		switch r.Method + " " + r.URL.Path {
+		case "POST /rest/com.deniz.jira.mapping/latest/":
+			created = append(created, Mapping{ID: 7, ProjectID: 1, ComponentID: 2, VersionID: 10})
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case "GET /rest/com.deniz.jira.mapping/latest/mappings":
+			json.NewEncoder(w).Encode(created)
+		default:
+			t.Errorf("Unexpected %s %s\n", r.Method, r.URL)
+		}
+	}, testPolicy)
+
+	m, err := client.CreateMapping("1", "2", "10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if m.ID != 7 {
+		t.Fatalf("Want the created mapping 7 but got %+v\n", m)
+	}
+	if n := requests.get("POST /rest/com.deniz.jira.mapping/latest/"); n != 1 {
+		t.Fatalf("Want 1 POST but got %d\n", n)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultJobNamePattern takes jobs to be of the form proj-component-release, where proj- and -release are
+// discarded and component is returned.
+var defaultJobNamePattern = regexp.MustCompile(`^[^-]*-(?P<component>.+)-[^-]*$`)
+
+// patternList is a flag.Value holding the job name patterns in the order given.
+type patternList []*regexp.Regexp
+
+func (p *patternList) String() string {
+	if p == nil {
+		return ""
+	}
+	s := make([]string, len(*p))
+	for i, re := range *p {
+		s[i] = re.String()
+	}
+	return strings.Join(s, " ")
+}
+
+// Set adds a pattern, which must have a named component or version group.
+func (p *patternList) Set(value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return err
+	}
+	if re.SubexpIndex("component") < 0 && re.SubexpIndex("version") < 0 {
+		return fmt.Errorf("job name pattern %s has neither a (?P<component>...) nor a (?P<version>...) group", value)
+	}
+	*p = append(*p, re)
+	return nil
+}
+
+// parseJobName returns the component name and release version name that the first of the patterns matching
+// the job name captures in its component and version groups.  Either may be empty if the pattern has no such
+// group.  Without patterns, defaultJobNamePattern is used.
+func parseJobName(jobName string, patterns []*regexp.Regexp) (component, version string, err error) {
+	if len(patterns) == 0 {
+		patterns = []*regexp.Regexp{defaultJobNamePattern}
+	}
+	for _, re := range patterns {
+		m := re.FindStringSubmatch(jobName)
+		if m == nil {
+			continue
+		}
+		if i := re.SubexpIndex("component"); i >= 0 {
+			component = m[i]
+		}
+		if i := re.SubexpIndex("version"); i >= 0 {
+			version = m[i]
+		}
+		return component, version, nil
+	}
+	return "", "", fmt.Errorf("job name %s matches none of the job name patterns %s", jobName, (*patternList)(&patterns))
+}
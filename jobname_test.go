@@ -4,8 +4,51 @@ import "testing"
 
 func TestJobNameToComponent(t *testing.T) {
 	j := "plat-a-bcd-release"
-	component := componentNameFromJobname(j)
+	component, _, err := parseJobName(j, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
 	if component != "a-bcd" {
 		t.Fatalf("Want a-bcd but got %s\n", component)
 	}
 }
+
+func TestJobNameNoMatch(t *testing.T) {
+	if _, _, err := parseJobName("svc-release", nil); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+}
+
+func TestJobNamePatterns(t *testing.T) {
+	var patterns patternList
+	for _, p := range []string{`^team-(?P<component>.+)-release-hotfix$`, `^(?P<component>[^-]+)-release-(?P<version>[0-9.]+)$`} {
+		if err := patterns.Set(p); err != nil {
+			t.Fatalf("Unexpected error: %v\n", err)
+		}
+	}
+
+	var tests = []struct {
+		jobName   string
+		component string
+		version   string
+	}{
+		{"team-svc-release-hotfix", "svc", ""},
+		{"svc-release-1.4", "svc", "1.4"},
+	}
+	for _, test := range tests {
+		component, version, err := parseJobName(test.jobName, patterns)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v\n", err)
+		}
+		if component != test.component || version != test.version {
+			t.Fatalf("%s: want %s %s but got %s %s\n", test.jobName, test.component, test.version, component, version)
+		}
+	}
+
+	if _, _, err := parseJobName("svc-release", patterns); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+	if err := patterns.Set(`^(.+)-release$`); err == nil {
+		t.Fatalf("Expected an error for a pattern without named groups\n")
+	}
+}
@@ -1,52 +1,186 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net/url"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/xoom/jira"
+	"github.com/xoom/kraken/jira"
+	"github.com/xoom/kraken/release"
 )
 
 var (
 	baseURL            = flag.String("jira-base-url", "http://localhost:8080", "JIRA base REST URL.  Required.")
-	username           = flag.String("jira-username", "", "JIRA admin user.  Required.")
-	password           = flag.String("jira-password", "", "JIRA admin password.  Required.")
+	username           = flag.String("jira-username", "", "JIRA admin user, or Atlassian account email for cloud-token.  Defaults to $KRAKEN_JIRA_USERNAME or the ~/.netrc login.  Required except for bearer.")
+	password           = flag.String("jira-password", "", "JIRA admin password.  Defaults to $KRAKEN_JIRA_PASSWORD, the jira-password-file contents or the ~/.netrc password.  Required for basic and session.")
+	authMethod         = flag.String("jira-auth", "basic", "How to authenticate with JIRA: basic, cloud-token (Jira Cloud API token), bearer (Data Center personal access token) or session (cookie session login).  Optional.")
+	token              = flag.String("jira-token", "", "JIRA API token or personal access token.  Defaults to $KRAKEN_JIRA_TOKEN.  Required for cloud-token and bearer.")
+	passwordFile       = flag.String("jira-password-file", "", "File holding the JIRA password, for example a mounted secret.  Optional.")
 	projectKey         = flag.String("project-key", "", "JIRA project key.  For example, PLAT.  Required.")
 	releaseVersionName = flag.String("release-version-name", "", "JIRA release version name. For example, 1.1.  Required.")
 	componentName      = flag.String("component-name", "", "JIRA project component name.  For example, rest-server.  Required if stashkins-job-name is not provided.")
 	jobName            = flag.String("stashkins-job-name", "", "Stashkins job name.  For example, eng-abcd-release, which extracts abcd as a component name.  Required if component-name is not provided.")
+	jobNamePatterns    patternList
 
 	nextVersionName = flag.String("next-version-name", "", "JIRA next version name. For example, 1.2.  Optional.")
-	versionFlag     = flag.Bool("version", false, "Print version and exit.")
-
-	Log = log.New(os.Stdout, "", log.Ldate|log.Ltime|log.Lshortfile)
+	releaseDate     = flag.String("release-date", "", "Release date, YYYY-MM-DD, for backdating a release.  Defaults to today.  Optional.")
+	timezone        = flag.String("timezone", "", "Time zone in which today's release date is computed, for example America/Los_Angeles or UTC.  Defaults to local time.  Optional.")
+	dateFormat      = flag.String("date-format", release.DefaultDateFormat, "Jira date picker format, in Java SimpleDateFormat notation, or auto to read it from Jira, which requires administrator permission.  Optional.")
+	forceDate       = flag.Bool("force-release-date", false, "Update the release date of a release mapping that is already released, for example for a hotfix redeploy.  Optional.")
+	failIfReleased  = flag.Bool("fail-if-released", false, "Exit with an error instead of skipping a release mapping that is already released.  Optional.")
+	allowUnresolved = flag.Bool("allow-unresolved", false, "Release the version even if issues of the component with the version as fix version are unresolved.  Optional.")
+	moveUnresolved  = flag.Bool("move-unresolved-to-next", false, "Release the version even if issues of the component with the version as fix version are unresolved, and move those issues to the next version.  Requires next-version-name or bump.  Optional.")
+	releaseJira     = flag.Bool("release-jira-version", false, "Also mark the Jira version released once all of its component mappings are released.  Optional.")
+	bump            = flag.String("bump", "", "Compute next-version-name by incrementing the major, minor or patch part of release-version-name, for example 2.2 for 2.1 and minor.  Optional.")
+	bumpUnused      = flag.Bool("bump-unused", false, "With bump, keep bumping past versions the project or component has already released.  Optional.")
+	versionFrom     = flag.String("version-from", "", "pom.xml, gradle.properties, package.json or VERSION file whose version, less -SNAPSHOT, is the release version name, and which incremented is the next version name.  Flags given override it.  Optional.")
+	releaseNotes    = flag.Bool("notes", false, "release: after releasing, write the release notes of the release version as the notes command does.  Optional.")
+	notesFormat     = flag.String("notes-format", "markdown", "Release notes and changelog format: markdown, html, text or json.  Optional.")
+	notesTemplate   = flag.String("notes-template", "", "Go template file replacing the default template of notes-format.  Optional.")
+	notesOutput     = flag.String("notes-output", "", "File the release notes are written to.  Defaults to stdout.  Optional.")
+	fromVersionName = flag.String("from", "", "diff: the version changed from, for example 2.1.  Its own issues are not listed.  Required for diff.")
+	toVersionName   = flag.String("to", "", "diff: the version changed to, for example 2.4.  Required for diff.")
+	diffOrder       = flag.String("diff-order", "version", "diff: order of the component's versions, version (number) or release-date.  Optional.")
+	format          = flag.String("format", "", "status: output format, table (the default) or json.  matrix: csv (the default), json, markdown or html.  Optional.")
+	output          = flag.String("output", "", "status, matrix: file the output is written to.  Defaults to stdout.  Optional.")
+	includeArchived = flag.Bool("include-archived", false, "matrix: include archived versions.  Optional.")
+	manifestPath    = flag.String("manifest", "", "YAML file listing the components to release, instead of component-name, release-version-name and next-version-name.  Optional.")
+	parallelism     = flag.Int("parallelism", 1, "Number of manifest components released concurrently.  Optional.")
+	dryRun          = flag.Bool("dry-run", false, "Print the Jira changes kraken would make, as text and JSON, and exit without making them.  Optional.")
+
+	deleteNextMapping = flag.Bool("delete-next-mapping", false, "rollback: delete the component's next-version mapping.  Requires next-version-name.  Optional.")
+	deleteNextVersion = flag.Bool("delete-next-version", false, "rollback: delete the next version if the journal records kraken creating it and nothing refers to it.  Requires next-version-name.  Optional.")
+
+	journalPath = flag.String("journal", "kraken-journal.jsonl", "File to which the Jira changes of each run are appended, for undo.  Empty disables the journal.  Optional.")
+	runID       = flag.String("run-id", "", "Run ID.  Generated if not provided.  undo: the ID of the run to undo.  Required for undo.")
+
+	retryMaxAttempts = flag.Int("retry-max-attempts", 4, "Number of attempts made at a Jira request that fails with a network error, 429 or 5xx.  Optional.")
+	retryBaseDelay   = flag.Duration("retry-base-delay", 500*time.Millisecond, "Delay before the first retry of a Jira request, doubled for each further retry.  Optional.")
+	retryMaxDelay    = flag.Duration("retry-max-delay", 30*time.Second, "Maximum delay between retries of a Jira request, including one asked for by Retry-After.  Optional.")
+	retryJitter      = flag.Float64("retry-jitter", 0.2, "Fraction, between 0 and 1, by which retry delays are randomly shortened.  Optional.")
+
+	configPath  = flag.String("config", "", "YAML config file of named profiles of flag values.  Defaults to ~/.config/kraken/config.yaml if it exists.  Optional.")
+	profileName = flag.String("profile", "", "Config profile whose values are used for the flags not given on the command line.  Defaults to the config's default-profile.  Optional.")
+
+	timeout = flag.Duration("timeout", 0, "Maximum duration of the whole run, for example 5m.  Zero means no limit.  Interrupting kraken cancels the run as well.  Optional.")
+
+	versionFlag = flag.Bool("version", false, "Print version and exit.")
+
+	// Log goes to stderr, so that stdout carries only the plan, notes, changelog, status or matrix.
+	Log = log.New(os.Stderr, "", log.Ldate|log.Ltime|log.Lshortfile)
 
 	buildInfo string
 )
 
+type command struct {
+	run      func(context.Context, *release.Releaser)
+	validate func() []error
+}
+
+// commands maps kraken's subcommands to their implementations.  release is the default.
+var commands = map[string]command{
+	"release":  command{run: runRelease, validate: validate},
+	"rollback": command{run: runRollback, validate: validateRollback},
+	"undo":     command{run: runUndo, validate: validateUndo},
+	"notes":    command{run: runNotes, validate: validateNotes},
+	"diff":     command{run: runDiff, validate: validateDiff},
+	"status":   command{run: runStatus, validate: validateStatus},
+	"matrix":   command{run: runMatrix, validate: validateMatrix},
+}
+
 func init() {
-	flag.Parse()
+	flag.Var(&jobNamePatterns, "job-name-pattern", "Regular expression extracting the component name from stashkins-job-name in a (?P<component>...) group, and optionally the release version name in a (?P<version>...) group.  May be repeated; the first matching pattern is used.  Defaults to proj-component-release.  Optional.")
+}
+
+func main() {
+	command, args := "release", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	flag.Usage = usage
+	flag.CommandLine.Parse(args)
 	Log.Printf("%s\n", buildInfo)
 	if *versionFlag {
 		os.Exit(0)
 	}
-}
 
-func main() {
-	if err := validate(); len(err) != 0 {
-		for _, i := range err {
-			Log.Printf("Error: %+v\nExiting.", i)
+	cmd, present := commands[command]
+	if !present {
+		failValidation(fmt.Errorf("unknown command %s", command))
+	}
+
+	if err := applyConfig(flag.CommandLine, *configPath, *profileName); err != nil {
+		failValidation(err)
+	}
+	if err := resolveCredentials(); err != nil {
+		failValidation(err)
+	}
+	if *versionFrom != "" && *manifestPath == "" {
+		if err := resolveVersions(); err != nil {
+			failValidation(err)
 		}
-		return
+	}
+	if err := cmd.validate(); len(err) != 0 {
+		failValidation(err...)
 	}
 
+	url, err := url.Parse(*baseURL)
+	if err != nil {
+		failValidation(fmt.Errorf("error parsing Jira base URL: %v", err))
+	}
+
+	retry := jira.RetryPolicy{MaxAttempts: *retryMaxAttempts, BaseDelay: *retryBaseDelay, MaxDelay: *retryMaxDelay, Jitter: *retryJitter}
+	releaser := release.NewReleaser(jira.NewClient(*username, *password, url, jira.WithAuthenticator(authenticator(url)), jira.WithRetryPolicy(retry), jira.WithLogger(Log)))
+	releaser.Log = Log
+
+	if *journalPath != "" {
+		id := release.NewRunID()
+		if *runID != "" && command != "undo" {
+			id = *runID
+		}
+		// A dry run only reads the journal, to check which versions kraken created.
+		releaser.Journal = release.NewJournal(*journalPath, id)
+		if !*dryRun {
+			Log.Printf("Run ID: %s, journal: %s\n", id, *journalPath)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// A second interrupt kills kraken.
+		stop()
+	}()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	cmd.run(ctx, releaser)
+}
+
+// resolveComponent derives the component name, and the release version name if not given, from the job name if
+// needed, normalizes the next version name, and logs the component name.
+func resolveComponent() {
 	if *componentName == "" {
-		*componentName = componentNameFromJobname(*jobName)
+		component, version, err := parseJobName(*jobName, jobNamePatterns)
+		if err != nil {
+			failValidation(err)
+		}
+		*componentName = component
+		if *releaseVersionName == "" {
+			*releaseVersionName = version
+		}
 	}
 
 	*nextVersionName = nextVersion(*nextVersionName)
@@ -56,151 +190,216 @@ func main() {
 	} else {
 		Log.Printf("Specified component: <%s>\n", *componentName)
 	}
+}
+
+// logVersionNames logs the release version name, and the next version name if given.
+func logVersionNames() {
 	Log.Printf("Specified release version: <%s>\n", *releaseVersionName)
 	if *nextVersionName != "" {
 		Log.Printf("Specified next version name: <%s>\n", *nextVersionName)
 	}
+}
 
-	url, err := url.Parse(*baseURL)
+// resolveVersions sets the release and next version names not given by flags to those of -version-from.
+func resolveVersions() error {
+	release, next, err := versionsFromFile(*versionFrom)
 	if err != nil {
-		Log.Printf("Error parsing Jira base URL: %v\n", err)
-		return
+		return err
 	}
-
-	jiraClient := jira.NewClient(*username, *password, url)
-
-	project, err := jiraClient.GetProject(*projectKey)
-	if err != nil {
-		Log.Printf("Error getting projects: %v\n", err)
-		return
+	if *releaseVersionName == "" {
+		*releaseVersionName = release
 	}
-	Log.Printf("Found project: %s\n", *projectKey)
-
-	versions, err := jiraClient.GetVersions(project.ID)
-	if err != nil {
-		Log.Printf("Error getting project versions: %v\n", err)
-		return
+	if *nextVersionName == "" && *bump == "" {
+		*nextVersionName = next
 	}
-	Log.Printf("Found %d project versions\n", len(versions))
+	return nil
+}
 
-	components, err := jiraClient.GetComponents(project.ID)
-	if err != nil {
-		Log.Printf("Error getting project components: %v\n", err)
-		return
+// resolveReleaseDate sets the releaser's date format and time zone from the flags, and returns -release-date
+// in that format, or the empty string for today.
+func resolveReleaseDate(ctx context.Context, releaser *release.Releaser) string {
+	if *timezone != "" {
+		// Validated before.
+		releaser.Location, _ = time.LoadLocation(*timezone)
 	}
-	Log.Printf("Found %d project components\n", len(components))
 
-	component, present := components[*componentName]
-	if !present {
-		Log.Printf("Component %s does not exist.\nExiting.\n", *componentName)
-		return
+	releaser.DateFormat = *dateFormat
+	if *dateFormat == "auto" {
+		format, err := releaser.DetectDateFormat(ctx)
+		if err != nil {
+			Log.Printf("%v.  Using the default date format %s.\n", err, release.DefaultDateFormat)
+			format = release.DefaultDateFormat
+		} else {
+			Log.Printf("Jira date format: %s\n", format)
+		}
+		releaser.DateFormat = format
 	}
 
-	// get mappings for all projects
-	mappings, err := jiraClient.GetMappings()
+	if *releaseDate == "" {
+		return ""
+	}
+	day, _ := time.Parse("2006-01-02", *releaseDate)
+	date, err := releaser.FormatDate(day)
 	if err != nil {
-		Log.Printf("Error getting mappings: %v\n", err)
-		return
+		failValidation(err)
 	}
+	return date
+}
 
-	// fetch or create release-version
-	releaseVersion, err := getOrCreateVersion(project.ID, *releaseVersionName, versions, jiraClient)
-	if err != nil {
-		Log.Printf("Error getOrCreateVersion(): %v\n", err)
+func runRelease(ctx context.Context, releaser *release.Releaser) {
+	if *manifestPath != "" {
+		runManifest(ctx, releaser)
 		return
 	}
 
-	// Create the release-version mapping if it does not exist
-	releaseMapping, err := getOrCreateMapping(project.ID, component.ID, releaseVersion.ID, mappings, jiraClient)
+	resolveComponent()
+	logVersionNames()
+	date := resolveReleaseDate(ctx, releaser)
+	result, err := releaser.Release(ctx, release.Request{
+		ProjectKey:         *projectKey,
+		ComponentName:      *componentName,
+		ReleaseVersionName: *releaseVersionName,
+		NextVersionName:    *nextVersionName,
+		ReleaseDate:        date,
+		ForceReleaseDate:   *forceDate,
+		FailIfReleased:     *failIfReleased,
+		ReleaseJiraVersion: *releaseJira,
+		AllowUnresolved:    *allowUnresolved,
+		MoveUnresolved:     *moveUnresolved,
+		Bump:               release.Bump(*bump),
+		BumpUnused:         *bumpUnused,
+		DryRun:             *dryRun,
+	})
 	if err != nil {
-		Log.Printf("Error getOrCreateMapping(): %v\n", err)
-		return
+		fail(err, result.Changed() && !*dryRun)
 	}
 
-	// Do not update a mapping that is already released.
-	if !releaseMapping.Released {
-		if err = jiraClient.UpdateReleasedFlag(releaseMapping.ID, true); err != nil {
-			Log.Printf("Error updating release flag for release-version: %v\n", err)
-			return
+	if *dryRun {
+		if err := printPlan(result.Plan); err != nil {
+			Log.Printf("Error printing plan: %v\n", err)
 		}
+	}
 
-		if err = jiraClient.UpdateReleaseDate(releaseMapping.ID, today()); err != nil {
-			Log.Printf("Error updating release data for release-version: %v\n", err)
+	if *releaseNotes {
+		// A dry run does not create the release version, so it has no notes yet.
+		if *dryRun && result.ReleaseVersionCreated {
+			Log.Printf("Dry run: skipping release notes, version %s does not exist yet\n", *releaseVersionName)
 			return
 		}
-		Log.Printf("Updated release date for release mapping %+v\n", releaseMapping)
-	} else {
-		Log.Printf("Skipping already released release mapping: %+v\n", releaseMapping)
+		if err := writeNotes(ctx, releaser, *projectKey, *componentName, *releaseVersionName); err != nil {
+			failNotes(err)
+		}
 	}
+}
 
-	// next-version
-	if *nextVersionName != "" {
-		nextVersion, err := getOrCreateVersion(project.ID, *nextVersionName, versions, jiraClient)
-		if err != nil {
-			Log.Printf("Error creating next version %s: %v\n", *nextVersionName, err)
-			return
-		}
+func runManifest(ctx context.Context, releaser *release.Releaser) {
+	requests, err := readManifest(*manifestPath, *projectKey)
+	if err != nil {
+		failValidation(err)
+	}
+	date := resolveReleaseDate(ctx, releaser)
+	for i := range requests {
+		requests[i].ReleaseDate = date
+		requests[i].ForceReleaseDate = *forceDate
+		requests[i].FailIfReleased = *failIfReleased
+		requests[i].ReleaseJiraVersion = *releaseJira
+		requests[i].AllowUnresolved = *allowUnresolved
+		requests[i].MoveUnresolved = *moveUnresolved
+		requests[i].Bump = release.Bump(*bump)
+		requests[i].BumpUnused = *bumpUnused
+	}
+	Log.Printf("Releasing %d component(s) from manifest %s\n", len(requests), *manifestPath)
 
-		// Create the next-version mapping if it does not exist.
-		_, err = getOrCreateMapping(project.ID, component.ID, nextVersion.ID, mappings, jiraClient)
-		if err != nil {
-			Log.Printf("Error creating next-version mapping: %v\n", err)
-			return
+	result := releaser.ReleaseBatch(ctx, release.Batch{Requests: requests, Parallelism: *parallelism, DryRun: *dryRun})
+
+	if *dryRun {
+		if err := printPlan(result.Plan); err != nil {
+			Log.Printf("Error printing plan: %v\n", err)
 		}
 	}
+	printBatchSummary(os.Stderr, result)
+
+	if failed := result.Failed(); failed != 0 {
+		Log.Printf("%d of %d component release(s) failed\n", failed, len(result.Components))
+		os.Exit(batchExitCode(result, *dryRun))
+	}
 }
 
-func getOrCreateVersion(projectID, versionName string, versions map[string]jira.Version, client jira.Core) (jira.Version, error) {
-	var err error
-	var present bool
-	var version jira.Version
-	version, present = versions[versionName]
-	if !present {
-		Log.Printf("Creating project version %s ...\n", versionName)
-		version, err = client.CreateVersion(projectID, versionName)
-		if err != nil {
-			return jira.Version{}, err
+func runRollback(ctx context.Context, releaser *release.Releaser) {
+	resolveComponent()
+	logVersionNames()
+	result, err := releaser.Rollback(ctx, release.RollbackRequest{
+		ProjectKey:         *projectKey,
+		ComponentName:      *componentName,
+		ReleaseVersionName: *releaseVersionName,
+		NextVersionName:    *nextVersionName,
+		DeleteNextMapping:  *deleteNextMapping,
+		DeleteNextVersion:  *deleteNextVersion,
+		DryRun:             *dryRun,
+	})
+	if err != nil {
+		fail(err, result.Changed() && !*dryRun)
+	}
+
+	if *dryRun {
+		if err := printPlan(result.Plan); err != nil {
+			Log.Printf("Error printing plan: %v\n", err)
 		}
-		Log.Printf("Created project version %s\n", version.Name)
-	} else {
-		Log.Printf("Retrieved existing version %s, no need to create it.\n", version.Name)
 	}
-	return version, nil
 }
 
-func getOrCreateMapping(projectID, componentID, releaseVersionID string, mappings map[int]jira.Mapping, client jira.ComponentVersions) (jira.Mapping, error) {
-	var mapping jira.Mapping
-	var present bool
-	var err error
+func runUndo(ctx context.Context, releaser *release.Releaser) {
+	result, err := releaser.Undo(ctx, release.UndoRequest{
+		JournalPath: *journalPath,
+		RunID:       *runID,
+		DryRun:      *dryRun,
+	})
+	if err != nil {
+		fail(err, len(result.Undone) != 0 && !*dryRun)
+	}
+	Log.Printf("Undid %d change(s) of run %s\n", len(result.Undone), *runID)
 
-	mapping, present = findMapping(mappings, projectID, componentID, releaseVersionID)
-	if !present {
-		mapping, err = client.CreateMapping(projectID, componentID, releaseVersionID)
-		if err != nil {
-			return jira.Mapping{}, err
+	if *dryRun {
+		if err := printPlan(result.Plan); err != nil {
+			Log.Printf("Error printing plan: %v\n", err)
 		}
-		Log.Printf("Created version mapping ID: %d\n", mapping.ID)
-	} else {
-		Log.Printf("Retrieved existing version mapping ID %d, no need to create it.\n", mapping.ID)
 	}
-	return mapping, nil
 }
 
-func findMapping(mappings map[int]jira.Mapping, projectID, componentID, versionID string) (jira.Mapping, bool) {
-	for _, mapping := range mappings {
-		pID := fmt.Sprintf("%d", mapping.ProjectID)
-		cID := fmt.Sprintf("%d", mapping.ComponentID)
-		vID := fmt.Sprintf("%d", mapping.VersionID)
-		if pID == projectID && cID == componentID && vID == versionID {
-			return mapping, true
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s [command] [flags]\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  release   Release a component version.  The default.\n")
+	fmt.Fprintf(os.Stderr, "  rollback  Mark a released component version unreleased.\n")
+	fmt.Fprintf(os.Stderr, "  undo      Revert the journaled Jira changes of the run given by -run-id.\n")
+	fmt.Fprintf(os.Stderr, "  notes     Write the release notes of a component version.\n")
+	fmt.Fprintf(os.Stderr, "  diff      Write the changelog of a component between the versions given by -from and -to.\n")
+	fmt.Fprintf(os.Stderr, "  status    Print the versions mapped to a component and their released state.\n")
+	fmt.Fprintf(os.Stderr, "  matrix    Write the component by version release matrix of a project.\n\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\n")
+	printExitCodes(os.Stderr)
+}
+
+// printPlan logs the plan one mutation per line, and prints the plan as JSON on stdout.
+func printPlan(plan []release.Mutation) error {
+	if len(plan) == 0 {
+		Log.Printf("Plan: no changes.\n")
+	} else {
+		Log.Printf("Plan: %d change(s)\n", len(plan))
+		for i, m := range plan {
+			Log.Printf("  %d. %s\n", i+1, m)
 		}
 	}
-	return jira.Mapping{}, false
-}
 
-func today() string {
-	t := time.Now()
-	return fmt.Sprintf("%d/%s/%d", t.Day(), t.Month().String()[:3], t.Year()%100)
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
 }
 
 // For inputs not ending in -SNAPSHOT, return the input.  For inputs ending -SNAPSHOT, remove that suffix and return the result.
@@ -211,39 +410,174 @@ func nextVersion(version string) string {
 	return version
 }
 
-// Jobs are assumed to be of the form proj-component-release, where proj- and -release are discarded and component is returned.
-func componentNameFromJobname(jobName string) string {
-	firstDash := strings.Index(jobName, "-")
-	lastDash := strings.LastIndex(jobName, "-")
-	return jobName[firstDash+1 : lastDash]
+// authenticator returns the Jira credentials selected by -jira-auth.
+func authenticator(baseURL *url.URL) jira.Authenticator {
+	switch *authMethod {
+	case "cloud-token":
+		return jira.CloudToken{Email: *username, Token: *token}
+	case "bearer":
+		return jira.BearerToken{Token: *token}
+	case "session":
+		return jira.NewSessionAuth(baseURL, *username, *password)
+	}
+	return jira.BasicAuth{Username: *username, Password: *password}
 }
 
-func validate() []error {
+// validateJira validates the flags every command needs to talk to Jira.
+func validateJira() []error {
 	errors := make([]error, 0)
 	if *baseURL == "" {
 		errors = append(errors, fmt.Errorf("jira-base-url must be provided"))
 	}
-	if *username == "" {
-		errors = append(errors, fmt.Errorf("jira-username must be provided"))
+	switch *authMethod {
+	case "basic", "session":
+		if *username == "" {
+			errors = append(errors, fmt.Errorf("jira-username must be provided, tried %s", credentialSources("jira-username", "KRAKEN_JIRA_USERNAME")))
+		}
+		if *password == "" {
+			errors = append(errors, fmt.Errorf("jira-password must be provided, tried %s", credentialSources("jira-password", "KRAKEN_JIRA_PASSWORD")))
+		}
+	case "cloud-token":
+		if *username == "" {
+			errors = append(errors, fmt.Errorf("jira-username must be provided, tried %s", credentialSources("jira-username", "KRAKEN_JIRA_USERNAME")))
+		}
+		if *token == "" {
+			errors = append(errors, fmt.Errorf("jira-token must be provided, tried %s", credentialSources("jira-token", "KRAKEN_JIRA_TOKEN")))
+		}
+	case "bearer":
+		if *token == "" {
+			errors = append(errors, fmt.Errorf("jira-token must be provided, tried %s", credentialSources("jira-token", "KRAKEN_JIRA_TOKEN")))
+		}
+	default:
+		errors = append(errors, fmt.Errorf("jira-auth must be basic, cloud-token, bearer or session"))
 	}
-	if *password == "" {
-		errors = append(errors, fmt.Errorf("jira-password must be provided"))
+	if *retryMaxAttempts < 1 {
+		errors = append(errors, fmt.Errorf("retry-max-attempts must be at least 1"))
 	}
-	if *projectKey == "" {
-		errors = append(errors, fmt.Errorf("project-key must be provided"))
+	if *retryBaseDelay < 0 || *retryMaxDelay < 0 {
+		errors = append(errors, fmt.Errorf("retry-base-delay and retry-max-delay must not be negative"))
 	}
-	if *releaseVersionName == "" {
+	if *timeout < 0 {
+		errors = append(errors, fmt.Errorf("timeout must not be negative"))
+	}
+	if *retryJitter < 0 || *retryJitter > 1 {
+		errors = append(errors, fmt.Errorf("retry-jitter must be between 0 and 1"))
+	}
+	return errors
+}
+
+// validate validates the flags of the release and rollback commands.
+func validate() []error {
+	errors := validateJira()
+	if *parallelism < 1 {
+		errors = append(errors, fmt.Errorf("parallelism must be at least 1"))
+	}
+	if *releaseDate != "" {
+		if _, err := time.Parse("2006-01-02", *releaseDate); err != nil {
+			errors = append(errors, fmt.Errorf("release-date must be YYYY-MM-DD"))
+		}
+	}
+	if _, err := time.LoadLocation(*timezone); err != nil {
+		errors = append(errors, fmt.Errorf("timezone: %v", err))
+	}
+	if *dateFormat != "auto" {
+		if _, err := release.FormatDate(time.Now(), *dateFormat); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	switch release.Bump(*bump) {
+	case "", release.BumpMajor, release.BumpMinor, release.BumpPatch:
+	default:
+		errors = append(errors, fmt.Errorf("bump must be major, minor or patch"))
+	}
+	if *forceDate && *failIfReleased {
+		errors = append(errors, fmt.Errorf("only one of force-release-date or fail-if-released may be provided"))
+	}
+	if *allowUnresolved && *moveUnresolved {
+		errors = append(errors, fmt.Errorf("only one of allow-unresolved or move-unresolved-to-next may be provided"))
+	}
+	if *bumpUnused && *bump == "" {
+		errors = append(errors, fmt.Errorf("bump-unused requires bump"))
+	}
+	if *releaseNotes {
+		errors = append(errors, validateNotesFormat()...)
+	}
+	if *manifestPath != "" {
+		if *releaseNotes {
+			errors = append(errors, fmt.Errorf("notes is not supported with manifest"))
+		}
+		if *componentName != "" || *jobName != "" || *releaseVersionName != "" || *nextVersionName != "" || *versionFrom != "" {
+			errors = append(errors, fmt.Errorf("manifest may not be combined with component-name, stashkins-job-name, release-version-name, next-version-name or version-from"))
+		}
+		return errors
+	}
+	errors = append(errors, validateComponent()...)
+	releaseVersion := *releaseVersionName
+	if *jobName != "" && *componentName == "" {
+		component, version, err := parseJobName(*jobName, jobNamePatterns)
+		if err != nil {
+			errors = append(errors, err)
+		} else if component == "" {
+			errors = append(errors, fmt.Errorf("job name pattern matching %s has no component group", *jobName))
+		}
+		if releaseVersion == "" {
+			releaseVersion = version
+		}
+	}
+	if releaseVersion == "" {
 		errors = append(errors, fmt.Errorf("release-version-name must be provided"))
 	}
-	if *nextVersionName != "" && *releaseVersionName == *nextVersionName {
+	if *nextVersionName != "" && *bump != "" {
+		errors = append(errors, fmt.Errorf("only one of next-version-name or bump may be provided"))
+	}
+	if *nextVersionName != "" && releaseVersion == *nextVersionName {
 		errors = append(errors, fmt.Errorf("release-version-name and next-version-name must be different"))
 	}
-	if *jobName != "" && *componentName != "" {
-		errors = append(errors, fmt.Errorf("only one of component-naem or stashkins-job-name may be provided"))
+	if *moveUnresolved && *nextVersionName == "" && *bump == "" {
+		errors = append(errors, fmt.Errorf("next-version-name or bump must be provided with move-unresolved-to-next"))
+	}
+	if (*deleteNextMapping || *deleteNextVersion) && *nextVersionName == "" {
+		errors = append(errors, fmt.Errorf("next-version-name must be provided with delete-next-mapping or delete-next-version"))
+	}
+
+	return errors
+}
+
+// validateComponent validates the flags naming the project and component of the commands working on one component.
+func validateComponent() []error {
+	var errors []error
+	if *projectKey == "" {
+		errors = append(errors, fmt.Errorf("project-key must be provided"))
 	}
 	if *jobName == "" && *componentName == "" {
 		errors = append(errors, fmt.Errorf("one of component-name or stashkins-job-name must be provided"))
 	}
+	if *jobName != "" && *componentName != "" {
+		errors = append(errors, fmt.Errorf("only one of component-name or stashkins-job-name may be provided"))
+	}
+	return errors
+}
 
+// validateRollback validates the flags of the rollback command.
+func validateRollback() []error {
+	errors := validate()
+	if *manifestPath != "" {
+		errors = append(errors, fmt.Errorf("manifest is not supported by rollback"))
+	}
+	if *bump != "" {
+		errors = append(errors, fmt.Errorf("bump is not supported by rollback, give next-version-name"))
+	}
+	return errors
+}
+
+// validateUndo validates the flags of the undo command.
+func validateUndo() []error {
+	errors := validateJira()
+	if *journalPath == "" {
+		errors = append(errors, fmt.Errorf("journal must be provided"))
+	}
+	if *runID == "" {
+		errors = append(errors, fmt.Errorf("run-id must be provided"))
+	}
 	return errors
 }
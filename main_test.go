@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/xoom/kraken/release"
+)
+
+// TestMain runs kraken instead of the tests when KRAKEN_TEST_MAIN is set, so that runKraken can check what a
+// kraken process writes to stdout.
+func TestMain(m *testing.M) {
+	if os.Getenv("KRAKEN_TEST_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeJiraResponses are the responses of fakeJiraServer by request path.  Project P has component rest-server,
+// released in 2.1 and mapped to 2.2, and searches for resolved issues find the bug P-1.
+var fakeJiraResponses = map[string]string{
+	"/rest/api/2/project/P":                                   `{"id": "1"}`,
+	"/rest/api/2/project/1/components":                        `[{"id": "100", "name": "rest-server"}]`,
+	"/rest/api/2/project/1/versions":                          `[{"id": "10", "name": "2.1", "projectId": 1}, {"id": "11", "name": "2.2", "projectId": 1}]`,
+	"/rest/com.deniz.jira.mapping/latest/mappings":            `[{"id": 1000, "projectId": 1, "componentId": 100, "versionId": 10, "released": true, "releaseDateStr": "3/Jan/15"}, {"id": 1001, "projectId": 1, "componentId": 100, "versionId": 11}]`,
+	"/rest/com.deniz.jira.mapping/latest/applicable_versions": `[{"id": 10, "name": "2.1", "isReleased": true}, {"id": 11, "name": "2.2"}]`,
+	"/rest/api/2/search":                                      `{"startAt": 0, "total": 1, "issues": [{"id": "1", "key": "P-1", "fields": {"summary": "Crash on start", "issuetype": {"name": "Bug"}, "resolution": {"name": "Fixed"}}}]}`,
+}
+
+// fakeJiraServer serves fakeJiraResponses, and fails the test on any request that would change Jira.
+func fakeJiraServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Unexpected %s %s\n", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body, present := fakeJiraResponses[r.URL.Path]
+		if r.URL.Path == "/rest/api/2/search" && strings.Contains(r.URL.Query().Get("jql"), "resolution = Unresolved") {
+			body = `{"startAt": 0, "total": 0, "issues": []}`
+		}
+		if !present {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// runKraken runs the kraken command with args against a fake Jira and returns what it wrote to stdout.  It fails
+// the test if kraken fails or logs nothing.
+func runKraken(t *testing.T, command string, args ...string) []byte {
+	stdout, code := runKrakenCode(t, command, args...)
+	if code != 0 {
+		t.Fatalf("kraken %s %v: want exit code 0 but got %d\n", command, args, code)
+	}
+	return stdout
+}
+
+// runKrakenCode runs the kraken command with args against a fake Jira and returns what it wrote to stdout and its
+// exit code.  It fails the test if kraken logs nothing.
+func runKrakenCode(t *testing.T, command string, args ...string) ([]byte, int) {
+	server := fakeJiraServer(t)
+	dir := t.TempDir()
+	args = append([]string{command, "-jira-base-url", server.URL, "-jira-username", "u", "-jira-password", "p", "-project-key", "P"}, args...)
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "KRAKEN_TEST_MAIN=1", "HOME="+dir)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("kraken %v: %v\n%s\n", args, err, stderr.String())
+	}
+	if stderr.Len() == 0 {
+		t.Fatalf("Want log lines on stderr\n")
+	}
+	return stdout.Bytes(), cmd.ProcessState.ExitCode()
+}
+
+func TestDryRunStdout(t *testing.T) {
+	out := runKraken(t, "release", "-dry-run", "-component-name", "rest-server", "-release-version-name", "2.2", "-next-version-name", "2.3")
+	var plan []release.Mutation
+	if err := json.Unmarshal(out, &plan); err != nil {
+		t.Fatalf("Want only the plan on stdout but got %v:\n%s\n", err, out)
+	}
+	if len(plan) == 0 {
+		t.Fatalf("Want a plan but got none\n")
+	}
+}
+
+func TestDryRunNotesOfNewVersion(t *testing.T) {
+	// Version 2.3 does not exist, so the dry run has no notes to write and prints only the plan.
+	out := runKraken(t, "release", "-dry-run", "-notes", "-component-name", "rest-server", "-release-version-name", "2.3", "-next-version-name", "2.4")
+	var plan []release.Mutation
+	if err := json.Unmarshal(out, &plan); err != nil {
+		t.Fatalf("Want only the plan on stdout but got %v:\n%s\n", err, out)
+	}
+}
+
+func TestNotesFailureAfterRelease(t *testing.T) {
+	_, code := runKrakenCode(t, "release", "-dry-run", "-notes", "-notes-output", "missing/notes.md", "-component-name", "rest-server", "-release-version-name", "2.2", "-next-version-name", "2.3")
+	if code != exitNotes {
+		t.Fatalf("Want exit code %d but got %d\n", exitNotes, code)
+	}
+}
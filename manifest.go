@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"text/tabwriter"
+
+	"github.com/xoom/kraken/release"
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// A manifest lists the component releases of one kraken run.
+	manifest struct {
+		Releases []manifestEntry `yaml:"releases"`
+	}
+
+	manifestEntry struct {
+		ProjectKey         string `yaml:"project-key"`
+		ComponentName      string `yaml:"component-name"`
+		ReleaseVersionName string `yaml:"release-version-name"`
+		NextVersionName    string `yaml:"next-version-name"`
+	}
+)
+
+// readManifest reads the YAML manifest at path and returns its entries as release requests.  Entries without a
+// project key get defaultProjectKey.  Next version names are normalized as with -next-version-name.
+func readManifest(path, defaultProjectKey string) ([]release.Request, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("error parsing manifest %s: %v", path, err)
+	}
+	if len(m.Releases) == 0 {
+		return nil, fmt.Errorf("manifest %s has no releases", path)
+	}
+
+	requests := make([]release.Request, 0, len(m.Releases))
+	for i, e := range m.Releases {
+		if e.ProjectKey == "" {
+			e.ProjectKey = defaultProjectKey
+		}
+		if e.ProjectKey == "" || e.ComponentName == "" || e.ReleaseVersionName == "" {
+			return nil, fmt.Errorf("manifest %s release %d must have project-key, component-name and release-version-name", path, i+1)
+		}
+		requests = append(requests, release.Request{
+			ProjectKey:         e.ProjectKey,
+			ComponentName:      e.ComponentName,
+			ReleaseVersionName: e.ReleaseVersionName,
+			NextVersionName:    nextVersion(e.NextVersionName),
+		})
+	}
+	return requests, nil
+}
+
+// printBatchSummary writes one row per component release to w.
+func printBatchSummary(w io.Writer, result release.BatchResult) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT\tCOMPONENT\tRELEASE\tNEXT\tSTATUS\tDETAIL")
+	for _, c := range result.Components {
+		status, detail := "ok", summarize(c.Result)
+		if c.Err != nil {
+			status, detail = "FAILED", c.Err.Error()
+		}
+		next := c.Request.NextVersionName
+		if c.Result.NextVersion.Name != "" {
+			// Computed by -bump.
+			next = c.Result.NextVersion.Name
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Request.ProjectKey, c.Request.ComponentName, c.Request.ReleaseVersionName, next, status, detail)
+	}
+	return tw.Flush()
+}
+
+func summarize(r release.Result) string {
+	var s string
+	switch {
+	case r.ReleasedFlagChanged:
+		s = "released " + r.ReleaseDate
+	case r.ReleaseDateChanged:
+		s = "already released, date changed to " + r.ReleaseDate
+	default:
+		s = "already released " + r.ReleaseDate
+	}
+	if len(r.MovedIssues) != 0 {
+		s += fmt.Sprintf(", moved %d issue(s) to %s", len(r.MovedIssues), r.NextVersion.Name)
+	}
+	return s
+}
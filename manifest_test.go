@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/xoom/kraken/release"
+)
+
+func writeTemp(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "kraken")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	return f.Name()
+}
+
+func TestReadManifest(t *testing.T) {
+	path := writeTemp(t, `
+releases:
+  - project-key: PLAT
+    component-name: rest-server
+    release-version-name: "2.1"
+    next-version-name: 2.2-SNAPSHOT
+  - component-name: web
+    release-version-name: "1.0"
+`)
+	defer os.Remove(path)
+
+	requests, err := readManifest(path, "BP")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Want 2 but got %d\n", len(requests))
+	}
+	if requests[0].ProjectKey != "PLAT" || requests[0].ReleaseVersionName != "2.1" || requests[0].NextVersionName != "2.2" {
+		t.Fatalf("Unexpected first request: %+v\n", requests[0])
+	}
+	if requests[1].ProjectKey != "BP" || requests[1].NextVersionName != "" {
+		t.Fatalf("Unexpected second request: %+v\n", requests[1])
+	}
+}
+
+func TestReadManifestMissingField(t *testing.T) {
+	path := writeTemp(t, `
+releases:
+  - project-key: PLAT
+    component-name: rest-server
+`)
+	defer os.Remove(path)
+
+	if _, err := readManifest(path, ""); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+}
+
+func TestReadManifestUnknownField(t *testing.T) {
+	path := writeTemp(t, `
+releases:
+  - project: PLAT
+`)
+	defer os.Remove(path)
+
+	if _, err := readManifest(path, ""); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+}
+
+func TestPrintBatchSummary(t *testing.T) {
+	result := release.BatchResult{Components: []release.ComponentResult{
+		{Request: release.Request{ProjectKey: "P", ComponentName: "a", ReleaseVersionName: "1.0"}, Result: release.Result{ReleasedFlagChanged: true, ReleaseDate: "1/Jan/15"}},
+		{Request: release.Request{ProjectKey: "P", ComponentName: "b", ReleaseVersionName: "1.0"}, Err: errors.New("Boom")},
+	}}
+
+	var buf bytes.Buffer
+	if err := printBatchSummary(&buf, result); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Want 3 but got %d\n", len(lines))
+	}
+	if !strings.Contains(lines[1], "ok") || !strings.Contains(lines[2], "FAILED") || !strings.Contains(lines[2], "Boom") {
+		t.Fatalf("Unexpected summary:\n%s\n", buf.String())
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/xoom/kraken/release"
+)
+
+// matrixFormats are the -format values of the matrix command.
+var matrixFormats = []string{"csv", "json", "markdown", "html"}
+
+// The templates of the markdown and html matrix formats.  They are executed with the release.Matrix and can
+// call cell with a release.Cell.
+const (
+	markdownMatrixTemplate = `# {{.Project}} release matrix
+
+| Component |{{range .Versions}} {{.}} |{{end}}
+|---|{{range .Versions}}---|{{end}}
+{{range .Rows}}| {{.Component}} |{{range .Cells}} {{cell .}} |{{end}}
+{{end}}`
+
+	htmlMatrixTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Project}} release matrix</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: center; white-space: nowrap; }
+th:first-child, td:first-child { text-align: left; }
+td.released { background: #d4edda; }
+td.unreleased { background: #fff3cd; }
+td.unmapped { color: #999; }
+</style>
+</head>
+<body>
+<h1>{{.Project}} release matrix</h1>
+<table>
+<tr><th>Component</th>{{range .Versions}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr><td>{{.Component}}</td>{{range .Cells}}<td class="{{.State}}">{{cell .}}</td>{{end}}</tr>
+{{end}}</table>
+<p><span style="background: #d4edda">released</span> <span style="background: #fff3cd">unreleased</span> <span style="color: #999">- unmapped</span></p>
+</body>
+</html>
+`
+)
+
+// cellText describes a cell in the markdown and html formats.
+func cellText(c release.Cell) string {
+	switch c.State {
+	case release.CellReleased:
+		if c.ReleaseDate == "" {
+			return "released"
+		}
+		return "released " + c.ReleaseDate
+	case release.CellUnreleased:
+		return "unreleased"
+	}
+	return "-"
+}
+
+// printMatrix writes the matrix to w in the given format.  In CSV each cell is its state, and in JSON each cell
+// also has the release date of released mappings.
+func printMatrix(w io.Writer, matrix release.Matrix, format string) error {
+	funcs := map[string]interface{}{"cell": cellText}
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(matrix, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case "markdown":
+		return template.Must(template.New(format).Funcs(funcs).Parse(markdownMatrixTemplate)).Execute(w, matrix)
+	case "html":
+		return htmltemplate.Must(htmltemplate.New(format).Funcs(funcs).Parse(htmlMatrixTemplate)).Execute(w, matrix)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"component"}, matrix.Versions...))
+	for _, row := range matrix.Rows {
+		record := []string{row.Component}
+		for _, c := range row.Cells {
+			record = append(record, string(c.State))
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func runMatrix(ctx context.Context, releaser *release.Releaser) {
+	matrix, err := releaser.Matrix(ctx, *projectKey, *includeArchived)
+	if err != nil {
+		fail(err, false)
+	}
+	err = writeOutput(*output, "release matrix", func(w io.Writer) error {
+		return printMatrix(w, matrix, *format)
+	})
+	if err != nil {
+		fail(err, false)
+	}
+}
+
+// validateMatrix validates the flags of the matrix command.
+func validateMatrix() []error {
+	errors := validateJira()
+	if *projectKey == "" {
+		errors = append(errors, fmt.Errorf("project-key must be provided"))
+	}
+	if *format != "" {
+		known := false
+		for _, f := range matrixFormats {
+			known = known || f == *format
+		}
+		if !known {
+			errors = append(errors, fmt.Errorf("format must be one of %s for matrix", strings.Join(matrixFormats, ", ")))
+		}
+	}
+	if *manifestPath != "" {
+		errors = append(errors, fmt.Errorf("manifest is not supported by matrix"))
+	}
+	return errors
+}
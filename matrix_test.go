@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xoom/kraken/release"
+)
+
+var testMatrix = release.Matrix{
+	Project:  "P",
+	Versions: []string{"1.1", "1.2"},
+	Rows: []release.MatrixRow{
+		{Component: "rest-server", Cells: []release.Cell{{State: release.CellReleased, ReleaseDate: "1/Jan/15"}, {State: release.CellUnreleased}}},
+		{Component: "<web>", Cells: []release.Cell{{State: release.CellUnmapped}, {State: release.CellUnmapped}}},
+	},
+}
+
+func TestPrintMatrix(t *testing.T) {
+	var tests = []struct {
+		format string
+		want   []string
+	}{
+		{"", []string{"component,1.1,1.2\nrest-server,released,unreleased\n<web>,unmapped,unmapped\n"}},
+		{"csv", []string{"component,1.1,1.2\n"}},
+		{"markdown", []string{"| Component | 1.1 | 1.2 |\n|---|---|---|\n| rest-server | released 1/Jan/15 | unreleased |\n| <web> | - | - |\n"}},
+		{"html", []string{"<tr><th>Component</th><th>1.1</th><th>1.2</th></tr>", `<td class="released">released 1/Jan/15</td>`, "<td>&lt;web&gt;</td>"}},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := printMatrix(&b, testMatrix, test.format); err != nil {
+			t.Fatalf("%s: unexpected error: %v\n", test.format, err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(b.String(), want) {
+				t.Fatalf("%s: want %q in\n%s\n", test.format, want, b.String())
+			}
+		}
+	}
+}
+
+func TestPrintMatrixJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := printMatrix(&b, testMatrix, "json"); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	var got release.Matrix
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(got.Rows) != 2 || got.Rows[0].Cells[0].ReleaseDate != "1/Jan/15" || got.Rows[1].Cells[1].State != release.CellUnmapped {
+		t.Fatalf("Unexpected matrix: %+v\n", got)
+	}
+}
+
+func TestMatrixStdout(t *testing.T) {
+	out := runKraken(t, "matrix")
+	records, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("Want only the matrix on stdout but got %v:\n%s\n", err, out)
+	}
+	want := [][]string{{"component", "2.1", "2.2"}, {"rest-server", "released", "unreleased"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("Want %v but got %v\n", want, records)
+	}
+
+	out = runKraken(t, "matrix", "-format", "json")
+	var matrix release.Matrix
+	if err := json.Unmarshal(out, &matrix); err != nil {
+		t.Fatalf("Want only the matrix on stdout but got %v:\n%s\n", err, out)
+	}
+}
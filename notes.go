@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/xoom/kraken/release"
+)
+
+// notesFormats are the -notes-format values.
+var notesFormats = []string{"markdown", "html", "text", "json"}
+
+// The default templates of the notes formats.  They are executed with notesData.
+const (
+	markdownNotesTemplate = `# {{.Component}} {{.Version}}
+{{if .ReleaseDate}}
+Released {{.ReleaseDate}}.
+{{end}}{{range .Groups}}
+## {{.Type}}
+
+{{range .Issues}}- [{{.Key}}]({{issueURL .Key}}) {{.Summary}}
+{{end}}{{else}}
+No resolved issues.
+{{end}}`
+
+	textNotesTemplate = `{{.Component}} {{.Version}}{{if .ReleaseDate}}, released {{.ReleaseDate}}{{end}}
+{{range .Groups}}
+{{.Type}}:
+{{range .Issues}}  {{.Key}}  {{.Summary}}
+{{end}}{{else}}
+No resolved issues.
+{{end}}`
+
+	htmlNotesTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Component}} {{.Version}}</title>
+</head>
+<body>
+<h1>{{.Component}} {{.Version}}</h1>
+{{if .ReleaseDate}}<p>Released {{.ReleaseDate}}.</p>
+{{end}}{{range .Groups}}<h2>{{.Type}}</h2>
+<ul>
+{{range .Issues}}<li><a href="{{issueURL .Key}}">{{.Key}}</a> {{.Summary}}</li>
+{{end}}</ul>
+{{else}}<p>No resolved issues.</p>
+{{end}}</body>
+</html>
+`
+)
+
+// notesTemplates are the default notes templates by format.
+var notesTemplates = map[string]string{
+	"markdown": markdownNotesTemplate,
+	"text":     textNotesTemplate,
+	"html":     htmlNotesTemplate,
+}
+
+// notesData is what notes templates are executed with.
+type notesData struct {
+	release.Notes
+	// BaseURL is -jira-base-url.
+	BaseURL string
+}
+
+// renderNotes writes notes to w in the given format.  A non-empty templateText replaces the format's default
+// template.
+func renderNotes(w io.Writer, notes release.Notes, format, templateText string) error {
+	data := notesData{Notes: notes, BaseURL: strings.TrimRight(*baseURL, "/")}
+	return render(w, format, templateText, notesTemplates, data, notes)
+}
+
+// render writes value to w as JSON for the json format, and otherwise executes the format's template from
+// templates, or templateText if it is not empty, with data.  For html the template is an html/template, for the
+// other formats a text/template.  templateText is used for json as well.  Templates can call issueURL with an
+// issue key.
+func render(w io.Writer, format, templateText string, templates map[string]string, data, value interface{}) error {
+	if format == "json" && templateText == "" {
+		out, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+	}
+	if templateText == "" {
+		templateText = templates[format]
+	}
+
+	base := strings.TrimRight(*baseURL, "/")
+	funcs := map[string]interface{}{
+		"issueURL": func(key string) string {
+			return base + "/browse/" + key
+		},
+	}
+	if format == "html" {
+		t, err := htmltemplate.New(format).Funcs(funcs).Parse(templateText)
+		if err != nil {
+			return fmt.Errorf("error parsing template: %v", err)
+		}
+		return t.Execute(w, data)
+	}
+	t, err := template.New(format).Funcs(funcs).Parse(templateText)
+	if err != nil {
+		return fmt.Errorf("error parsing template: %v", err)
+	}
+	return t.Execute(w, data)
+}
+
+// writeNotes reads the release notes of the component version and writes them to -notes-output, or stdout.
+func writeNotes(ctx context.Context, releaser *release.Releaser, project, component, version string) error {
+	notes, err := releaser.Notes(ctx, release.NotesRequest{ProjectKey: project, ComponentName: component, VersionName: version})
+	if err != nil {
+		return err
+	}
+	templateText, err := readTemplate()
+	if err != nil {
+		return err
+	}
+	return writeOutput(*notesOutput, "release notes", func(w io.Writer) error {
+		return renderNotes(w, notes, *notesFormat, templateText)
+	})
+}
+
+// readTemplate returns the contents of -notes-template, or the empty string if it is not given.
+func readTemplate() (string, error) {
+	if *notesTemplate == "" {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(*notesTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error reading template: %v", err)
+	}
+	return string(data), nil
+}
+
+// writeOutput calls write with the file at path, or stdout if path is empty.  what names the output in the log.
+func writeOutput(path, what string, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	Log.Printf("Wrote %s to %s\n", what, path)
+	return nil
+}
+
+func runNotes(ctx context.Context, releaser *release.Releaser) {
+	resolveComponent()
+	Log.Printf("Specified release version: <%s>\n", *releaseVersionName)
+	if err := writeNotes(ctx, releaser, *projectKey, *componentName, *releaseVersionName); err != nil {
+		fail(err, false)
+	}
+}
+
+// validateNotes validates the flags of the notes command.
+func validateNotes() []error {
+	errors := validateJira()
+	errors = append(errors, validateNotesFormat()...)
+	errors = append(errors, validateComponent()...)
+	if *releaseVersionName == "" && *jobName == "" {
+		errors = append(errors, fmt.Errorf("release-version-name must be provided"))
+	}
+	if *manifestPath != "" {
+		errors = append(errors, fmt.Errorf("manifest is not supported by notes"))
+	}
+	return errors
+}
+
+// validateNotesFormat validates -notes-format and -notes-template.
+func validateNotesFormat() []error {
+	var errors []error
+	known := false
+	for _, f := range notesFormats {
+		known = known || f == *notesFormat
+	}
+	if !known {
+		errors = append(errors, fmt.Errorf("notes-format must be one of %s", strings.Join(notesFormats, ", ")))
+	}
+	if *notesTemplate != "" {
+		if _, err := os.Stat(*notesTemplate); err != nil {
+			errors = append(errors, fmt.Errorf("notes-template: %v", err))
+		}
+	}
+	return errors
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/xoom/kraken/release"
+)
+
+var testNotes = release.Notes{
+	Project:     "P",
+	Component:   "rest-server",
+	Version:     "1.1",
+	ReleaseDate: "1/Jan/15",
+	Groups: []release.IssueGroup{
+		{Type: "Bug", Issues: []release.NoteIssue{{Key: "P-1", Summary: "Crash <on> start"}}},
+	},
+}
+
+func TestRenderNotes(t *testing.T) {
+	saved := *baseURL
+	defer func() { *baseURL = saved }()
+	*baseURL = "https://jira.example.com/"
+
+	var tests = []struct {
+		format string
+		want   []string
+	}{
+		{"markdown", []string{"# rest-server 1.1\n", "Released 1/Jan/15.", "## Bug\n", "- [P-1](https://jira.example.com/browse/P-1) Crash <on> start\n"}},
+		{"text", []string{"rest-server 1.1, released 1/Jan/15\n", "Bug:\n", "  P-1  Crash <on> start\n"}},
+		{"html", []string{"<h2>Bug</h2>", `<a href="https://jira.example.com/browse/P-1">P-1</a> Crash &lt;on&gt; start`}},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := renderNotes(&b, testNotes, test.format, ""); err != nil {
+			t.Fatalf("%s: unexpected error: %v\n", test.format, err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(b.String(), want) {
+				t.Fatalf("%s: want %q in\n%s\n", test.format, want, b.String())
+			}
+		}
+	}
+}
+
+func TestRenderNotesJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := renderNotes(&b, testNotes, "json", ""); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	var got release.Notes
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if got.Version != "1.1" || len(got.Groups) != 1 || got.Groups[0].Issues[0].Key != "P-1" {
+		t.Fatalf("Unexpected notes: %+v\n", got)
+	}
+}
+
+func TestRenderNotesTemplate(t *testing.T) {
+	var b bytes.Buffer
+	template := "{{.Version}}:{{range .Groups}}{{range .Issues}} {{.Key}}{{end}}{{end}}"
+	if err := renderNotes(&b, testNotes, "json", template); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if b.String() != "1.1: P-1" {
+		t.Fatalf("Want 1.1: P-1 but got %q\n", b.String())
+	}
+
+	if err := renderNotes(&b, testNotes, "markdown", "{{.Nope"); err == nil {
+		t.Fatalf("Want error\n")
+	}
+}
+
+func TestNotesStdout(t *testing.T) {
+	out := runKraken(t, "notes", "-component-name", "rest-server", "-release-version-name", "2.1", "-notes-format", "json")
+	var notes release.Notes
+	if err := json.Unmarshal(out, &notes); err != nil {
+		t.Fatalf("Want only the notes on stdout but got %v:\n%s\n", err, out)
+	}
+	if notes.Version != "2.1" || len(notes.Groups) != 1 || notes.Groups[0].Issues[0].Key != "P-1" {
+		t.Fatalf("Unexpected notes: %+v\n", notes)
+	}
+}
@@ -0,0 +1,195 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/xoom/kraken/jira"
+)
+
+type (
+	// Batch is a set of component releases made in one run.
+	Batch struct {
+		Requests []Request
+		// Parallelism is the number of requests released concurrently.  Values below 1 mean 1.
+		Parallelism int
+		// DryRun records the writes of the whole batch instead of making them.  Request.DryRun is ignored.
+		DryRun bool
+	}
+
+	// BatchResult reports the outcome of each request of a batch, in request order.
+	BatchResult struct {
+		Components []ComponentResult
+
+		// Plan holds the writes a dry run would have made for the whole batch, in request order.
+		Plan []Mutation
+	}
+
+	// ComponentResult is the outcome of one request of a batch.  Err is nil if the request succeeded.
+	ComponentResult struct {
+		Request Request
+		Result  Result
+		Err     error
+	}
+)
+
+// ReleaseBatch releases each request of the batch as Release would, reading each project, its versions and
+// components once and the mappings of all projects once for the whole batch.  Up to Parallelism requests are
+// released concurrently; requests sharing a version never both create it.  The log lines of each request are
+// prefixed with its project key and component name and logged together, in request order.  A failed request
+// does not stop the requests after it.
+func (r *Releaser) ReleaseBatch(ctx context.Context, batch Batch) BatchResult {
+	client, plan := r.clientFor(ctx, batch.DryRun)
+	today, err := r.today()
+	if err != nil {
+		var result BatchResult
+		for _, req := range batch.Requests {
+			result.Components = append(result.Components, ComponentResult{Request: req, Err: err})
+		}
+		return result
+	}
+	reqs := make([]Request, len(batch.Requests))
+	for i, req := range batch.Requests {
+		req.DryRun = batch.DryRun
+		if req.ReleaseDate == "" {
+			req.ReleaseDate = today
+		}
+		reqs[i] = req
+	}
+
+	states, errs := r.loadBatch(ctx, client, reqs)
+
+	parallelism := batch.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	results := make([]ComponentResult, len(reqs))
+	logs := make([]bytes.Buffer, len(reqs))
+	done := make([]chan struct{}, len(reqs))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	jobs := make(chan int)
+	for w := 0; w < parallelism; w++ {
+		go func() {
+			for i := range jobs {
+				req := reqs[i]
+				worker := &Releaser{
+					client:     r.client,
+					Log:        log.New(&logs[i], fmt.Sprintf("[%s/%s] ", req.ProjectKey, req.ComponentName), r.Log.Flags()),
+					Journal:    r.Journal,
+					DateFormat: r.DateFormat,
+					Location:   r.Location,
+				}
+				results[i] = worker.releaseOne(ctx, client, plan, states[req.ProjectKey], errs[i], req)
+				close(done[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range reqs {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	var result BatchResult
+	for i := range reqs {
+		<-done[i]
+		r.Log.Writer().Write(logs[i].Bytes())
+		result.Components = append(result.Components, results[i])
+		result.Plan = append(result.Plan, results[i].Result.Plan...)
+	}
+	return result
+}
+
+// loadBatch reads the mappings of all projects and each project of the requests once.  It returns the state of
+// each project it could read and, per request, the error that prevents releasing it, if any.
+func (r *Releaser) loadBatch(ctx context.Context, client jira.Jira, reqs []Request) (map[string]*projectState, []error) {
+	states := make(map[string]*projectState)
+	errs := make([]error, len(reqs))
+	projectErrs := make(map[string]error)
+	var shared *projectState
+	var mappingsErr error
+
+	for i, req := range reqs {
+		if errs[i] = req.validate(); errs[i] != nil {
+			continue
+		}
+		if errs[i] = ctx.Err(); errs[i] != nil {
+			continue
+		}
+
+		if shared == nil && mappingsErr == nil {
+			var m map[int]jira.Mapping
+			if m, mappingsErr = r.loadMappings(client); mappingsErr == nil {
+				shared = newProjectState(jira.Project{}, nil, nil)
+				shared.mappings = m
+			}
+		}
+		if errs[i] = mappingsErr; errs[i] != nil {
+			continue
+		}
+
+		if _, present := states[req.ProjectKey]; present {
+			continue
+		}
+		if err, failed := projectErrs[req.ProjectKey]; failed {
+			errs[i] = err
+			continue
+		}
+		state, err := r.loadProject(client, req.ProjectKey)
+		if err != nil {
+			projectErrs[req.ProjectKey] = err
+			errs[i] = err
+			continue
+		}
+		state.share(shared)
+		states[req.ProjectKey] = state
+	}
+	return states, errs
+}
+
+// releaseOne releases one request of a batch, unless err says it cannot be.
+func (r *Releaser) releaseOne(ctx context.Context, client jira.Jira, plan *planner, state *projectState, err error, req Request) ComponentResult {
+	r.Log.Printf("Releasing %s %s %s\n", req.ProjectKey, req.ComponentName, req.ReleaseVersionName)
+	result := ComponentResult{Request: req, Err: err}
+	if err != nil {
+		r.Log.Printf("Error: %v\n", err)
+		return result
+	}
+
+	var fork *planner
+	if plan != nil {
+		fork = plan.fork()
+		client = fork
+	}
+
+	component, err := state.component(req.ComponentName)
+	if err == nil {
+		result.Result, err = r.release(ctx, client, state, component, req)
+	}
+	if fork != nil {
+		result.Result.Plan = fork.Plan()
+	}
+	if err != nil {
+		r.Log.Printf("Error: %v\n", err)
+		result.Err = err
+	}
+	return result
+}
+
+// Failed returns the number of requests that failed.
+func (b BatchResult) Failed() int {
+	n := 0
+	for _, c := range b.Components {
+		if c.Err != nil {
+			n++
+		}
+	}
+	return n
+}
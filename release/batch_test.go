@@ -0,0 +1,125 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xoom/kraken/jira"
+)
+
+// countingJira counts the reads of the wrapped fake.
+type countingJira struct {
+	*fakeJira
+	projects int
+	mappings int
+}
+
+func (r *countingJira) GetVersions(projectID string) (map[string]jira.Version, error) {
+	r.projects++
+	return r.fakeJira.GetVersions(projectID)
+}
+
+func (r *countingJira) GetMappings() (map[int]jira.Mapping, error) {
+	r.mappings++
+	return r.fakeJira.GetMappings()
+}
+
+func TestReleaseBatch(t *testing.T) {
+	fake := newFakeJira()
+	fake.components["web"] = jira.Component{ID: "3", Name: "web"}
+	client := &countingJira{fakeJira: fake}
+
+	batch := Batch{Requests: []Request{
+		{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2"},
+		{ProjectKey: "P", ComponentName: "nope", ReleaseVersionName: "1.1"},
+		{ProjectKey: "P", ComponentName: "web", ReleaseVersionName: "1.1", NextVersionName: "1.2"},
+	}}
+	result := NewReleaser(client).ReleaseBatch(context.Background(), batch)
+
+	if result.Failed() != 1 || result.Components[1].Err == nil {
+		t.Fatalf("Want only the second request to fail but got %+v\n", result)
+	}
+	if client.projects != 1 || client.mappings != 1 {
+		t.Fatalf("Want 1 project read and 1 mappings read but got %d and %d\n", client.projects, client.mappings)
+	}
+
+	// The second component reuses the versions the first created.
+	web := result.Components[2].Result
+	if web.ReleaseVersionCreated || web.NextVersionCreated || !web.ReleaseMappingCreated || !web.NextMappingCreated {
+		t.Fatalf("Unexpected web result: %+v\n", web)
+	}
+	if len(fake.versions) != 2 || len(fake.mappings) != 4 {
+		t.Fatalf("Want 2 versions and 4 mappings but got %d and %d\n", len(fake.versions), len(fake.mappings))
+	}
+}
+
+func TestReleaseBatchDryRun(t *testing.T) {
+	fake := newFakeJira()
+	fake.components["web"] = jira.Component{ID: "3", Name: "web"}
+
+	batch := Batch{Requests: []Request{
+		{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1"},
+		{ProjectKey: "P", ComponentName: "web", ReleaseVersionName: "1.1"},
+	}, DryRun: true}
+	result := NewReleaser(fake).ReleaseBatch(context.Background(), batch)
+
+	if fake.writes != 0 {
+		t.Fatalf("Want 0 writes but got %d\n", fake.writes)
+	}
+	// create version, then create mapping, flag and date for each component
+	if len(result.Plan) != 7 || len(result.Components[0].Result.Plan) != 4 || len(result.Components[1].Result.Plan) != 3 {
+		t.Fatalf("Unexpected plan: %+v\n", result)
+	}
+}
+
+func TestReleaseBatchParallel(t *testing.T) {
+	fake := newFakeJira()
+	var requests []Request
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("c%d", i)
+		fake.components[name] = jira.Component{ID: strconv.Itoa(200 + i), Name: name}
+		requests = append(requests, Request{ProjectKey: "P", ComponentName: name, ReleaseVersionName: "1.1", NextVersionName: "1.2"})
+	}
+
+	var buf bytes.Buffer
+	releaser := NewReleaser(fake)
+	releaser.Log = log.New(&buf, "", 0)
+	result := releaser.ReleaseBatch(context.Background(), Batch{Requests: requests, Parallelism: 8})
+
+	if result.Failed() != 0 {
+		t.Fatalf("Want 0 failures but got %d\n", result.Failed())
+	}
+	if len(fake.versions) != 2 || len(fake.mappings) != 40 {
+		t.Fatalf("Want 2 versions and 40 mappings but got %d and %d\n", len(fake.versions), len(fake.mappings))
+	}
+	created := 0
+	for _, c := range result.Components {
+		if c.Result.ReleaseVersionCreated {
+			created++
+		}
+	}
+	if created != 1 {
+		t.Fatalf("Want the release version created once but got %d\n", created)
+	}
+
+	// Each component's log lines are together and in request order.
+	component := -1
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !strings.HasPrefix(line, "[P/c") {
+			continue
+		}
+		n, _ := strconv.Atoi(line[len("[P/c"):strings.Index(line, "]")])
+		if n < component {
+			t.Fatalf("Log of c%d after log of c%d\n", n, component)
+		}
+		component = n
+	}
+	if component != 19 {
+		t.Fatalf("Want logs up to c19 but got c%d\n", component)
+	}
+}
@@ -0,0 +1,87 @@
+package release
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xoom/kraken/jira"
+)
+
+// Bump names the part of the release version that is incremented to compute the next version.
+type Bump string
+
+const (
+	BumpMajor Bump = "major"
+	BumpMinor Bump = "minor"
+	BumpPatch Bump = "patch"
+)
+
+// BumpVersion returns version with the bumped part incremented and the parts after it zeroed.  version is a
+// semantic version, optionally with a v prefix and with fewer than three parts: BumpVersion("v2.1", BumpMinor)
+// is v2.2, and BumpVersion("2.1", BumpPatch) is 2.1.1.  A pre-release or build suffix is dropped.
+func BumpVersion(version string, bump Bump) (string, error) {
+	var index int
+	switch bump {
+	case BumpMajor:
+		index = 0
+	case BumpMinor:
+		index = 1
+	case BumpPatch:
+		index = 2
+	default:
+		return "", fmt.Errorf("unknown bump %s, want major, minor or patch", bump)
+	}
+
+	prefix, rest := "", version
+	if strings.HasPrefix(rest, "v") || strings.HasPrefix(rest, "V") {
+		prefix, rest = rest[:1], rest[1:]
+	}
+	if i := strings.IndexAny(rest, "-+"); i >= 0 {
+		rest = rest[:i]
+	}
+	fields := strings.Split(rest, ".")
+	if len(fields) > 3 {
+		return "", fmt.Errorf("version %s has more than three parts", version)
+	}
+	parts := make([]int, len(fields))
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("version %s is not a semantic version", version)
+		}
+		parts[i] = n
+	}
+
+	for len(parts) <= index {
+		parts = append(parts, 0)
+	}
+	parts[index]++
+	for i := index + 1; i < len(parts); i++ {
+		parts[i] = 0
+	}
+
+	s := make([]string, len(parts))
+	for i, n := range parts {
+		s[i] = strconv.Itoa(n)
+	}
+	return prefix + strings.Join(s, "."), nil
+}
+
+// nextVersionName returns the request's next version name, bumping the release version if the request asks
+// for it.  With BumpUnused, versions the project or the component has already released are skipped.
+func nextVersionName(state *projectState, component jira.Component, req Request) (string, error) {
+	if req.NextVersionName != "" || req.Bump == "" {
+		return req.NextVersionName, nil
+	}
+	next, err := BumpVersion(req.ReleaseVersionName, req.Bump)
+	if err != nil {
+		return "", err
+	}
+	for req.BumpUnused && state.released(component, next) {
+		if next, err = BumpVersion(next, req.Bump); err != nil {
+			return "", err
+		}
+	}
+	return next, nil
+}
@@ -0,0 +1,58 @@
+package release
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xoom/kraken/jira"
+)
+
+func TestBumpVersion(t *testing.T) {
+	var tests = []struct {
+		version string
+		bump    Bump
+		want    string
+	}{
+		{"1.2.3", BumpMajor, "2.0.0"},
+		{"1.2.3", BumpMinor, "1.3.0"},
+		{"1.2.3", BumpPatch, "1.2.4"},
+		{"v2.1", BumpMinor, "v2.2"},
+		{"2.1", BumpPatch, "2.1.1"},
+		{"2.1", BumpMajor, "3.0"},
+		{"1.0.0-rc.1", BumpPatch, "1.0.1"},
+	}
+	for _, test := range tests {
+		got, err := BumpVersion(test.version, test.bump)
+		if err != nil {
+			t.Fatalf("%s %s: unexpected error: %v\n", test.version, test.bump, err)
+		}
+		if got != test.want {
+			t.Fatalf("%s %s: want %s but got %s\n", test.version, test.bump, test.want, got)
+		}
+	}
+
+	for _, version := range []string{"1.x", "1.2.3.4", ""} {
+		if _, err := BumpVersion(version, BumpMinor); err == nil {
+			t.Fatalf("%s: expected an error\n", version)
+		}
+	}
+	if _, err := BumpVersion("1.2", "huge"); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+}
+
+func TestReleaseBumpUnused(t *testing.T) {
+	client := newFakeJira()
+	client.versions["1.2"] = jira.Version{ID: "50", Name: "1.2", Released: true}
+	client.versions["1.3"] = jira.Version{ID: "51", Name: "1.3"}
+	client.mappings[60] = jira.Mapping{ID: 60, ProjectID: 1, ComponentID: 2, VersionID: 51, Released: true}
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", Bump: BumpMinor, BumpUnused: true}
+
+	result, err := NewReleaser(client).Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if result.NextVersion.Name != "1.4" || !result.NextVersionCreated {
+		t.Fatalf("Want 1.4 created but got %+v\n", result.NextVersion)
+	}
+}
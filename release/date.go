@@ -0,0 +1,128 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xoom/kraken/jira"
+)
+
+// DefaultDateFormat is the Jira date picker format of a Jira installed with default settings.
+const DefaultDateFormat = "d/MMM/yy"
+
+// dateFormatProperty is the Jira application property holding the date picker format.
+const dateFormatProperty = "jira.date.picker.java.format"
+
+// Go layouts of the Java SimpleDateFormat date fields FormatDate supports.
+var dateLayouts = map[string]string{
+	"d":    "2",
+	"dd":   "02",
+	"M":    "1",
+	"MM":   "01",
+	"MMM":  "Jan",
+	"MMMM": "January",
+	"yy":   "06",
+	"yyyy": "2006",
+	"EEE":  "Mon",
+	"EEEE": "Monday",
+}
+
+// FormatDate formats t in the Jira date picker format, which is written in Java SimpleDateFormat notation, for
+// example d/MMM/yy or yyyy-MM-dd.
+func FormatDate(t time.Time, format string) (string, error) {
+	layout, err := dateLayout(format)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(layout), nil
+}
+
+// dateLayout converts a Java SimpleDateFormat date format to a Go time layout.
+func dateLayout(format string) (string, error) {
+	var layout strings.Builder
+	for i := 0; i < len(format); {
+		c := format[i]
+		switch {
+		case c == '\'':
+			// Quoted text; '' is a quote.
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				return "", fmt.Errorf("date format %s has an unterminated quote", format)
+			}
+			if end == 0 {
+				layout.WriteByte('\'')
+			} else {
+				layout.WriteString(format[i+1 : i+1+end])
+			}
+			i += end + 2
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i
+			for j < len(format) && format[j] == c {
+				j++
+			}
+			l, present := dateLayouts[format[i:j]]
+			if !present {
+				return "", fmt.Errorf("date format %s: %s is not supported", format, format[i:j])
+			}
+			layout.WriteString(l)
+			i = j
+		case c >= '0' && c <= '9':
+			// Go would read digits as layout fields.
+			return "", fmt.Errorf("date format %s: digits are not supported", format)
+		default:
+			layout.WriteByte(c)
+			i++
+		}
+	}
+	return layout.String(), nil
+}
+
+// DetectDateFormat returns the date picker format Jira is configured with.  Reading it requires administrator
+// permission.
+func (r *Releaser) DetectDateFormat(ctx context.Context) (string, error) {
+	format, err := jira.WithContext(ctx, r.client).GetApplicationProperty(dateFormatProperty)
+	if err != nil {
+		return "", fmt.Errorf("error getting the Jira date format: %w", err)
+	}
+	if _, err := dateLayout(format); err != nil {
+		return "", err
+	}
+	return format, nil
+}
+
+// today returns today's date in the Releaser's location and date format.
+func (r *Releaser) today() (string, error) {
+	loc := r.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return r.FormatDate(time.Now().In(loc))
+}
+
+// FormatDate formats t in the Releaser's date format.
+func (r *Releaser) FormatDate(t time.Time) (string, error) {
+	format := r.DateFormat
+	if format == "" {
+		format = DefaultDateFormat
+	}
+	return FormatDate(t, format)
+}
+
+// isoDate converts a release date in the Releaser's date format to yyyy-MM-dd, the format of Jira version dates.
+func (r *Releaser) isoDate(date string) (string, error) {
+	format := r.DateFormat
+	if format == "" {
+		format = DefaultDateFormat
+	}
+	layout, err := dateLayout(format)
+	if err != nil {
+		return "", err
+	}
+	t, err := time.Parse(layout, date)
+	if err != nil {
+		return "", fmt.Errorf("release date %s is not in the date format %s", date, format)
+	}
+	return t.Format("2006-01-02"), nil
+}
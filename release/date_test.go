@@ -0,0 +1,64 @@
+package release
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFormatDate(t *testing.T) {
+	day := time.Date(2015, time.January, 2, 0, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		format string
+		want   string
+	}{
+		{DefaultDateFormat, "2/Jan/15"},
+		{"yyyy-MM-dd", "2015-01-02"},
+		{"dd.MM.yyyy", "02.01.2015"},
+		{"EEE, d MMMM yyyy", "Fri, 2 January 2015"},
+		{"d 'de' MMMM", "2 de January"},
+	}
+	for _, test := range tests {
+		got, err := FormatDate(day, test.format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v\n", test.format, err)
+		}
+		if got != test.want {
+			t.Fatalf("%s: want %s but got %s\n", test.format, test.want, got)
+		}
+	}
+
+	for _, format := range []string{"d/MMM/yy HH:mm", "yyyy-MM-dd'", "d/M/2015"} {
+		if _, err := FormatDate(day, format); err == nil {
+			t.Fatalf("%s: expected an error\n", format)
+		}
+	}
+}
+
+type dateFormatJira struct {
+	*fakeJira
+	format string
+}
+
+func (r *dateFormatJira) GetApplicationProperty(key string) (string, error) {
+	return r.format, nil
+}
+
+func TestDetectDateFormat(t *testing.T) {
+	client := &dateFormatJira{newFakeJira(), "yyyy-MM-dd"}
+	releaser := NewReleaser(client)
+	format, err := releaser.DetectDateFormat(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	releaser.DateFormat = format
+	releaser.Location = time.UTC
+
+	result, err := releaser.Release(context.Background(), Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if want := time.Now().UTC().Format("2006-01-02"); result.ReleaseDate != want {
+		t.Fatalf("Want %s but got %s\n", want, result.ReleaseDate)
+	}
+}
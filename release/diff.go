@@ -0,0 +1,210 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xoom/kraken/jira"
+)
+
+// Order is the order in which Diff puts the versions of a component.
+type Order string
+
+const (
+	// OrderVersion orders versions by version number, for example 2.9 before 2.10.
+	OrderVersion Order = "version"
+	// OrderReleaseDate orders versions by release date, unreleased versions last.
+	OrderReleaseDate Order = "release-date"
+)
+
+type (
+	// DiffRequest describes the changes of a component between two of its versions.
+	DiffRequest struct {
+		// ProjectKey is the Jira project key, for example PLAT.
+		ProjectKey string
+		// ComponentName is the Jira project component name, for example rest-server.
+		ComponentName string
+		// FromVersionName is the version changed from, for example 2.1.  Its own issues are not listed.
+		FromVersionName string
+		// ToVersionName is the version changed to, for example 2.4.
+		ToVersionName string
+		// Order orders the component's versions.  Defaults to OrderVersion.
+		Order Order
+	}
+
+	// Changelog lists the release notes of the component versions after From up to and including To, oldest first.
+	Changelog struct {
+		Project   string  `json:"project"`
+		Component string  `json:"component"`
+		From      string  `json:"from"`
+		To        string  `json:"to"`
+		Versions  []Notes `json:"versions"`
+	}
+
+	// componentVersion is a version mapped to a component, with the sort keys of Diff.
+	componentVersion struct {
+		version jira.Version
+		// date is the release date of the mapping, or else of the Jira version, as yyyy-MM-dd, or empty.
+		date string
+	}
+)
+
+// Diff reads the release notes of the versions mapped to the component that come after the from version, up to
+// and including the to version.  Diff makes no changes to Jira.
+func (r *Releaser) Diff(ctx context.Context, req DiffRequest) (Changelog, error) {
+	if err := req.validate(); err != nil {
+		return Changelog{}, err
+	}
+	client := jira.WithContext(ctx, r.client)
+
+	state, component, err := r.load(client, req.ProjectKey, req.ComponentName)
+	if err != nil {
+		return Changelog{}, err
+	}
+	versions, err := r.componentVersions(client, state, component)
+	if err != nil {
+		return Changelog{}, err
+	}
+	order := req.Order
+	if order == "" {
+		order = OrderVersion
+	}
+	sortVersions(versions, order)
+
+	from, to := -1, -1
+	for i, v := range versions {
+		switch v.version.Name {
+		case req.FromVersionName:
+			from = i
+		case req.ToVersionName:
+			to = i
+		}
+	}
+	if from < 0 {
+		return Changelog{}, fmt.Errorf("component %s has no version %s", component.Name, req.FromVersionName)
+	}
+	if to < 0 {
+		return Changelog{}, fmt.Errorf("component %s has no version %s", component.Name, req.ToVersionName)
+	}
+	if from > to {
+		return Changelog{}, fmt.Errorf("version %s comes after %s in %s order", req.FromVersionName, req.ToVersionName, order)
+	}
+
+	changelog := Changelog{Project: req.ProjectKey, Component: component.Name, From: req.FromVersionName, To: req.ToVersionName, Versions: []Notes{}}
+	for _, v := range versions[from+1 : to+1] {
+		if err := ctx.Err(); err != nil {
+			return changelog, err
+		}
+		notes, err := r.notes(client, state, req.ProjectKey, component, v.version)
+		if err != nil {
+			return changelog, err
+		}
+		changelog.Versions = append(changelog.Versions, notes)
+	}
+	return changelog, nil
+}
+
+// componentVersions returns the versions mapped to the component, with their release dates.
+func (r *Releaser) componentVersions(client jira.Jira, state *projectState, component jira.Component) ([]componentVersion, error) {
+	cvs, err := client.GetVersionsForComponent(state.project.ID, component.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting versions of component %s: %w", component.Name, err)
+	}
+
+	byID := make(map[string]jira.Version)
+	state.mu.Lock()
+	for _, v := range state.versions {
+		byID[v.ID] = v
+	}
+	state.mu.Unlock()
+	var versions []componentVersion
+	for id, cv := range cvs {
+		v, present := byID[strconv.Itoa(id)]
+		if !present {
+			v = jira.Version{ID: strconv.Itoa(id), Name: cv.Name}
+		}
+		c := componentVersion{version: v, date: v.ReleaseDate}
+		if m, present := state.mapping(component.ID, v.ID); present && m.Released && m.ReleaseDateStr != "" {
+			if date, err := r.isoDate(m.ReleaseDateStr); err == nil {
+				c.date = date
+			}
+		}
+		versions = append(versions, c)
+	}
+	return versions, nil
+}
+
+// sortVersions sorts versions in the given order.  Versions equal in release date order are in version order.
+func sortVersions(versions []componentVersion, order Order) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+		if order == OrderReleaseDate && a.date != b.date {
+			// Unreleased versions come last.
+			if a.date == "" || b.date == "" {
+				return b.date == ""
+			}
+			return a.date < b.date
+		}
+		return compareVersions(a.version.Name, b.version.Name) < 0
+	})
+}
+
+// compareVersions compares version names part by part, numerically where both parts are numbers, so that 2.9
+// comes before 2.10.  A v prefix is ignored.  It returns -1, 0 or 1 like strings.Compare.
+func compareVersions(a, b string) int {
+	as := versionParts(a)
+	bs := versionParts(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		m, merr := strconv.Atoi(as[i])
+		n, nerr := strconv.Atoi(bs[i])
+		switch {
+		case merr == nil && nerr == nil && m != n:
+			if m < n {
+				return -1
+			}
+			return 1
+		case merr != nil || nerr != nil:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+func versionParts(version string) []string {
+	version = strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V")
+	return strings.FieldsFunc(version, func(r rune) bool {
+		return r == '.' || r == '-' || r == '+'
+	})
+}
+
+func (req DiffRequest) validate() error {
+	if req.ProjectKey == "" {
+		return fmt.Errorf("project key must be provided")
+	}
+	if req.ComponentName == "" {
+		return fmt.Errorf("component name must be provided")
+	}
+	if req.FromVersionName == "" || req.ToVersionName == "" {
+		return fmt.Errorf("from and to version names must be provided")
+	}
+	if req.FromVersionName == req.ToVersionName {
+		return fmt.Errorf("from and to version names must be different")
+	}
+	switch req.Order {
+	case "", OrderVersion, OrderReleaseDate:
+	default:
+		return fmt.Errorf("unknown order %s, want version or release-date", req.Order)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package release
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xoom/kraken/jira"
+)
+
+func TestCompareVersions(t *testing.T) {
+	var tests = []struct {
+		a, b string
+		want int
+	}{
+		{"2.9", "2.10", -1},
+		{"2.10", "2.9", 1},
+		{"v1.2", "1.2", 0},
+		{"1.2", "1.2.1", -1},
+		{"1.2-rc1", "1.2-rc2", -1},
+		{"1.a", "1.b", -1},
+	}
+	for _, test := range tests {
+		if got := compareVersions(test.a, test.b); got != test.want {
+			t.Fatalf("compareVersions(%s, %s): want %d but got %d\n", test.a, test.b, test.want, got)
+		}
+	}
+}
+
+// diffFakeJira maps rest-server to versions 2.1, 2.2, 2.10 and 2.9, released in that order.
+func diffFakeJira(t *testing.T) *fakeJira {
+	client := newFakeJira()
+	releaser := NewReleaser(client)
+	for i, name := range []string{"2.1", "2.2", "2.10", "2.9"} {
+		req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: name, ReleaseDate: []string{"1/Jan/15", "2/Jan/15", "3/Jan/15", "4/Jan/15"}[i]}
+		if _, err := releaser.Release(context.Background(), req); err != nil {
+			t.Fatalf("Unexpected error: %v\n", err)
+		}
+	}
+	for name, v := range client.versions {
+		client.found[notesJQL("1", v.ID, "2")] = []jira.Issue{{Key: "P-" + name}}
+	}
+	return client
+}
+
+func versionNames(c Changelog) []string {
+	var names []string
+	for _, n := range c.Versions {
+		names = append(names, n.Version)
+	}
+	return names
+}
+
+func TestDiff(t *testing.T) {
+	client := diffFakeJira(t)
+	req := DiffRequest{ProjectKey: "P", ComponentName: "rest-server", FromVersionName: "2.1", ToVersionName: "2.10"}
+
+	changelog, err := NewReleaser(client).Diff(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if got := versionNames(changelog); len(got) != 3 || got[0] != "2.2" || got[1] != "2.9" || got[2] != "2.10" {
+		t.Fatalf("Want 2.2, 2.9 and 2.10 but got %v\n", got)
+	}
+	if issues := changelog.Versions[1].Groups[0].Issues; issues[0].Key != "P-2.9" {
+		t.Fatalf("Want P-2.9 but got %+v\n", issues)
+	}
+
+	// 2.9 was released after 2.10.
+	req.Order = OrderReleaseDate
+	changelog, err = NewReleaser(client).Diff(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if got := versionNames(changelog); len(got) != 2 || got[0] != "2.2" || got[1] != "2.10" {
+		t.Fatalf("Want 2.2 and 2.10 but got %v\n", got)
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	client := diffFakeJira(t)
+	for _, req := range []DiffRequest{
+		{ProjectKey: "P", ComponentName: "rest-server", FromVersionName: "2.10", ToVersionName: "2.1"},
+		{ProjectKey: "P", ComponentName: "rest-server", FromVersionName: "2.1", ToVersionName: "3.0"},
+		{ProjectKey: "P", ComponentName: "rest-server", FromVersionName: "2.1", ToVersionName: "2.2", Order: "size"},
+	} {
+		if _, err := NewReleaser(client).Diff(context.Background(), req); err == nil {
+			t.Fatalf("%+v: want error\n", req)
+		}
+	}
+}
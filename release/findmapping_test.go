@@ -1,9 +1,9 @@
-package main
+package release
 
 import (
 	"testing"
 
-	"github.com/xoom/jira"
+	"github.com/xoom/kraken/jira"
 )
 
 func TestFindMapping(t *testing.T) {
@@ -1,10 +1,10 @@
-package main
+package release
 
 import (
 	"errors"
 	"testing"
 
-	"github.com/xoom/jira"
+	"github.com/xoom/kraken/jira"
 )
 
 type componentVersions struct {
@@ -35,30 +35,37 @@ func (r componentVersions) DeleteMapping(mappingID int) error {
 }
 
 func TestGetOrCreateMappingMapHit(t *testing.T) {
-	mappings := map[int]jira.Mapping{1: jira.Mapping{ID: 1, ProjectID: 1, ComponentID: 2, VersionID: 3}}
-	m, err := getOrCreateMapping("1", "2", "3", mappings, componentVersions{})
+	state := newProjectState(jira.Project{ID: "1"}, nil, nil)
+	state.mappings = map[int]jira.Mapping{1: jira.Mapping{ID: 1, ProjectID: 1, ComponentID: 2, VersionID: 3}}
+	m, created, err := NewReleaser(nil).getOrCreateMapping(state, "2", "3", componentVersions{})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v\n", err)
 	}
 	if m.ID != 1 {
 		t.Fatalf("Want 1 but got: %v\n", m.ID)
 	}
+	if created {
+		t.Fatalf("Want false\n")
+	}
 }
 
 func TestGetOrCreateMappingMapMiss(t *testing.T) {
-	mappings := map[int]jira.Mapping{}
-	m, err := getOrCreateMapping("1", "2", "3", mappings, componentVersions{mapping: jira.Mapping{ID: 2, ProjectID: 4, ComponentID: 6, VersionID: 8}})
+	state := newProjectState(jira.Project{ID: "1"}, nil, nil)
+	m, created, err := NewReleaser(nil).getOrCreateMapping(state, "2", "3", componentVersions{mapping: jira.Mapping{ID: 2, ProjectID: 4, ComponentID: 6, VersionID: 8}})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v\n", err)
 	}
 	if m.ID != 2 {
 		t.Fatalf("Want 2 but got: %v\n", m.ID)
 	}
+	if !created {
+		t.Fatalf("Want true\n")
+	}
 }
 
 func TestGetOrCreateMappingMapMissAndError(t *testing.T) {
 	client := componentVersions{err: errors.New("Boom")}
-	_, err := getOrCreateMapping("1", "2", "3", map[int]jira.Mapping{}, client)
+	_, _, err := NewReleaser(nil).getOrCreateMapping(newProjectState(jira.Project{ID: "1"}, nil, nil), "2", "3", client)
 	if err == nil {
 		t.Fatalf("Expected an error\n")
 	}
@@ -1,10 +1,10 @@
-package main
+package release
 
 import (
 	"errors"
 	"testing"
 
-	"github.com/xoom/jira"
+	"github.com/xoom/kraken/jira"
 )
 
 type core struct {
@@ -34,29 +34,35 @@ func (r core) CreateVersion(projectID, versionName string) (jira.Version, error)
 
 func TestGetOrCreateVersionMapHit(t *testing.T) {
 	versions := map[string]jira.Version{"v1": jira.Version{Name: "v1"}, "v2": jira.Version{Name: "v2"}}
-	v, err := getOrCreateVersion("1", "v1", versions, core{})
+	v, created, err := NewReleaser(nil).getOrCreateVersion(newProjectState(jira.Project{ID: "1"}, versions, nil), "v1", core{})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v\n", err)
 	}
 	if v.Name != "v1" {
 		t.Fatalf("Want v1 but got: %v\n", v.Name)
 	}
+	if created {
+		t.Fatalf("Want false\n")
+	}
 }
 
 func TestGetOrCreateVersionMapMiss(t *testing.T) {
 	client := core{version: jira.Version{Name: "v1"}}
-	v, err := getOrCreateVersion("1", "v1", map[string]jira.Version{}, client)
+	v, created, err := NewReleaser(nil).getOrCreateVersion(newProjectState(jira.Project{ID: "1"}, map[string]jira.Version{}, nil), "v1", client)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v\n", err)
 	}
 	if v.Name != "v1" {
 		t.Fatalf("Want v1 but got: %v\n", v.Name)
 	}
+	if !created {
+		t.Fatalf("Want true\n")
+	}
 }
 
 func TestGetOrCreateVersionMapMissAndError(t *testing.T) {
 	client := core{err: errors.New("Boom")}
-	_, err := getOrCreateVersion("1", "v1", map[string]jira.Version{}, client)
+	_, _, err := NewReleaser(nil).getOrCreateVersion(newProjectState(jira.Project{ID: "1"}, map[string]jira.Version{}, nil), "v1", client)
 	if err == nil {
 		t.Fatalf("Expected an error\n")
 	}
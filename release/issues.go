@@ -0,0 +1,106 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xoom/kraken/jira"
+)
+
+// unresolvedJQL returns the JQL query for the unresolved issues of the component with the version as fix version.
+// It refers to the project, version and component by ID so that their names need no quoting.
+func unresolvedJQL(projectID, versionID, componentID string) string {
+	return fmt.Sprintf("project = %s AND fixVersion = %s AND component = %s AND resolution = Unresolved ORDER BY key", projectID, versionID, componentID)
+}
+
+// unresolvedIssues returns the unresolved issues of the component with the named version as fix version.  A
+// version that does not exist yet has none.
+func (r *Releaser) unresolvedIssues(client jira.Core, state *projectState, component jira.Component, versionName string) ([]jira.Issue, error) {
+	version, present := state.version(versionName)
+	if !present {
+		return nil, nil
+	}
+	issues, err := client.Search(unresolvedJQL(state.project.ID, version.ID, component.ID), []string{"summary", "status"})
+	if err != nil {
+		return nil, fmt.Errorf("error searching unresolved issues of version %s: %w", versionName, err)
+	}
+	return issues, nil
+}
+
+// checkUnresolved records the keys of the unresolved issues of the release version in result, and returns
+// ErrUnresolvedIssues if there are any and req does not allow them.  A release mapping that is already released
+// is not checked again, unless req moves its stragglers.
+func (r *Releaser) checkUnresolved(client jira.Core, state *projectState, component jira.Component, req Request, result *Result) error {
+	if state.released(component, req.ReleaseVersionName) && !req.MoveUnresolved {
+		return nil
+	}
+	issues, err := r.unresolvedIssues(client, state, component, req.ReleaseVersionName)
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+
+	result.UnresolvedIssues = issueKeys(issues)
+	if req.MoveUnresolved {
+		r.Log.Printf("Found %d unresolved issue(s) of version %s to move to the next version: %s\n", len(issues), req.ReleaseVersionName, strings.Join(result.UnresolvedIssues, ", "))
+		return nil
+	}
+	if req.AllowUnresolved {
+		r.Log.Printf("Releasing version %s with %d unresolved issue(s): %s\n", req.ReleaseVersionName, len(issues), strings.Join(result.UnresolvedIssues, ", "))
+		return nil
+	}
+	return fmt.Errorf("%w: component %s version %s has %d: %s", ErrUnresolvedIssues, component.Name, req.ReleaseVersionName, len(issues), strings.Join(result.UnresolvedIssues, ", "))
+}
+
+// moveIssues moves the result's unresolved issues from the release version to the next version, and records their
+// keys in result.  If moving one fails, moveIssues moves the issues it has moved back before returning the error.
+func (r *Releaser) moveIssues(ctx context.Context, client jira.Core, result *Result) error {
+	from, to := result.ReleaseVersion, result.NextVersion
+	for _, key := range result.UnresolvedIssues {
+		err := ctx.Err()
+		if err == nil {
+			err = client.UpdateFixVersions(key, jira.FixVersionsUpdate{Add: []string{to.ID}, Remove: []string{from.ID}})
+		}
+		if err != nil {
+			err = fmt.Errorf("error moving issue %s from version %s to %s: %w", key, from.Name, to.Name, err)
+			r.Log.Printf("%v, moving %d moved issue(s) back\n", err, len(result.MovedIssues))
+			return r.moveIssuesBack(client, result, err)
+		}
+		result.MovedIssues = append(result.MovedIssues, key)
+		r.Log.Printf("Moved issue %s from version %s to %s\n", key, from.Name, to.Name)
+	}
+	return nil
+}
+
+// moveBackTimeout bounds the moves back of moveIssuesBack.
+const moveBackTimeout = time.Minute
+
+// moveIssuesBack undoes the moves of moveIssues after cause, most recent first.  Issues it fails to move back stay
+// in result.MovedIssues, and the journal can undo their moves.  The cause may be the cancellation or deadline of
+// the context client is bound to, so the moves back are made with a context of their own.
+func (r *Releaser) moveIssuesBack(client jira.Core, result *Result, cause error) error {
+	client, cancel := r.detachedClient(client, moveBackTimeout)
+	defer cancel()
+	from, to := result.ReleaseVersion, result.NextVersion
+	for i := len(result.MovedIssues) - 1; i >= 0; i-- {
+		key := result.MovedIssues[i]
+		if err := client.UpdateFixVersions(key, jira.FixVersionsUpdate{Add: []string{from.ID}, Remove: []string{to.ID}}); err != nil {
+			return fmt.Errorf("%w; error moving issue %s back to version %s: %v", cause, key, from.Name, err)
+		}
+		result.MovedIssues = result.MovedIssues[:i]
+		r.Log.Printf("Moved issue %s back to version %s\n", key, from.Name)
+	}
+	return cause
+}
+
+func issueKeys(issues []jira.Issue) []string {
+	keys := make([]string, len(issues))
+	for i, issue := range issues {
+		keys[i] = issue.Key
+	}
+	return keys
+}
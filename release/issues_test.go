@@ -0,0 +1,100 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/xoom/kraken/jira"
+)
+
+// cancelOnWrite calls cancel when a log line contains text.
+type cancelOnWrite struct {
+	text   string
+	cancel context.CancelFunc
+}
+
+func (c cancelOnWrite) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), c.text) {
+		c.cancel()
+	}
+	return len(p), nil
+}
+
+func TestMoveIssuesBackAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var updates []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Update struct {
+				FixVersions []map[string]struct{ ID string } `json:"fixVersions"`
+			} `json:"update"`
+		}
+		if r.Method != "PUT" {
+			t.Errorf("Unexpected %s %s\n", r.Method, r.URL)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, op := range body.Update.FixVersions {
+			for verb, version := range op {
+				updates = append(updates, r.URL.Path+" "+verb+" "+version.ID)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	baseURL, _ := url.Parse(server.URL)
+
+	releaser := NewReleaser(jira.NewClient("u", "p", baseURL, jira.WithLogger(log.New(ioutil.Discard, "", 0))))
+	// Ctrl-C once the first issue is moved.
+	releaser.Log = log.New(cancelOnWrite{"Moved issue P-1 ", cancel}, "", 0)
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	releaser.Journal = NewJournal(path, "run-1")
+	client, _ := releaser.clientFor(ctx, false)
+	result := Result{
+		ReleaseVersion:   jira.Version{ID: "10", Name: "1.1"},
+		NextVersion:      jira.Version{ID: "11", Name: "1.2"},
+		UnresolvedIssues: []string{"P-1", "P-2"},
+	}
+
+	if err := releaser.moveIssues(ctx, client, &result); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Want %v but got %v\n", context.Canceled, err)
+	}
+	want := []string{
+		"/rest/api/2/issue/P-1 remove 10", "/rest/api/2/issue/P-1 add 11",
+		"/rest/api/2/issue/P-1 remove 11", "/rest/api/2/issue/P-1 add 10",
+	}
+	if len(updates) != len(want) {
+		t.Fatalf("Want %v but got %v\n", want, updates)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Fatalf("Want %v but got %v\n", want, updates)
+		}
+	}
+	if len(result.MovedIssues) != 0 {
+		t.Fatalf("Want no moved issues but got %v\n", result.MovedIssues)
+	}
+
+	// The move back is journaled like the move.
+	entries, err := ReadJournal(path, "run-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(entries) != 2 || entries[1].Op != OpMoveIssue || entries[1].FromVersionID != "11" || entries[1].VersionID != "10" {
+		t.Fatalf("Want the move and the move back but got %+v\n", entries)
+	}
+}
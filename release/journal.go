@@ -0,0 +1,315 @@
+package release
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xoom/kraken/jira"
+)
+
+type (
+	// Journal appends the Jira writes made by one kraken run to a JSON-lines file, so that a run that fails
+	// halfway can be undone.
+	Journal struct {
+		Path  string
+		RunID string
+
+		mu sync.Mutex
+	}
+
+	// Entry is one journaled Jira write.  The previous values are those of the mapping before the write, and
+	// are set for released flag and release date updates and for deleted mappings.
+	Entry struct {
+		RunID string    `json:"runId"`
+		Time  time.Time `json:"time"`
+		Mutation
+		PreviousReleased    bool   `json:"previousReleased"`
+		PreviousReleaseDate string `json:"previousReleaseDate"`
+	}
+
+	// journaler is a Jira client that records each successful write of the wrapped client in a journal.  It
+	// remembers the versions and mappings it reads so that it can record previous values.  It is safe for
+	// concurrent use.
+	journaler struct {
+		jira.Jira
+		journal *Journal
+
+		mu       sync.Mutex
+		versions map[string]jira.Version
+		mappings map[int]jira.Mapping
+	}
+)
+
+// NewJournal returns a journal that appends entries for the given run ID to the file at path.
+func NewJournal(path, runID string) *Journal {
+	return &Journal{Path: path, RunID: runID}
+}
+
+// NewRunID returns a run ID made of the current UTC time and a random suffix, for example 20150102T150405Z-9f3a.
+func NewRunID() string {
+	b := make([]byte, 2)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%x", time.Now().UTC().Format("20060102T150405Z"), b)
+}
+
+// Append writes the entry to the end of the journal file, creating the file if needed.
+func (j *Journal) Append(e Entry) error {
+	e.RunID = j.RunID
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.OpenFile(j.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadJournal returns the entries in the journal file at path for the given run ID, in the order they were written.
+func ReadJournal(path, runID string) ([]Entry, error) {
+	return readEntries(path, func(e Entry) bool {
+		return e.RunID == runID
+	})
+}
+
+// CreatedVersion reports whether any run in the journal created the version with the given ID.  A journal file
+// that does not exist yet records no versions.
+func (j *Journal) CreatedVersion(versionID string) (bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries, err := readEntries(j.Path, func(e Entry) bool {
+		return e.Op == OpCreateVersion && e.VersionID == versionID
+	})
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return len(entries) != 0, err
+}
+
+// readEntries returns the entries in the journal file at path for which keep returns true, in the order they were
+// written.
+func readEntries(path string, keep func(Entry) bool) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("error reading journal %s line %d: %w", path, line, err)
+		}
+		if keep(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func newJournaler(client jira.Jira, journal *Journal) *journaler {
+	return &journaler{Jira: client, journal: journal, versions: make(map[string]jira.Version), mappings: make(map[int]jira.Mapping)}
+}
+
+// rebind returns a journaler over client that appends to j's journal and knows the versions and mappings j has
+// seen.
+func (j *journaler) rebind(client jira.Jira) *journaler {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	n := newJournaler(client, j.journal)
+	for id, v := range j.versions {
+		n.versions[id] = v
+	}
+	for id, m := range j.mappings {
+		n.mappings[id] = m
+	}
+	return n
+}
+
+func (j *journaler) record(e Entry) error {
+	if err := j.journal.Append(e); err != nil {
+		return fmt.Errorf("%s was made in Jira but could not be journaled: %w", e.Mutation, err)
+	}
+	return nil
+}
+
+func (j *journaler) GetVersions(projectID string) (map[string]jira.Version, error) {
+	versions, err := j.Jira.GetVersions(projectID)
+	if err != nil {
+		return nil, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, v := range versions {
+		j.versions[v.ID] = v
+	}
+	return versions, nil
+}
+
+func (j *journaler) GetMappings() (map[int]jira.Mapping, error) {
+	mappings, err := j.Jira.GetMappings()
+	if err != nil {
+		return nil, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for id, m := range mappings {
+		j.mappings[id] = m
+	}
+	return mappings, nil
+}
+
+func (j *journaler) CreateVersion(projectID, versionName string) (jira.Version, error) {
+	v, err := j.Jira.CreateVersion(projectID, versionName)
+	if err != nil {
+		return v, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.versions[v.ID] = v
+	return v, j.record(Entry{Mutation: Mutation{Op: OpCreateVersion, ProjectID: projectID, VersionID: v.ID, VersionName: versionName}})
+}
+
+func (j *journaler) DeleteVersion(versionID string) error {
+	if err := j.Jira.DeleteVersion(versionID); err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	v := j.versions[versionID]
+	delete(j.versions, versionID)
+	projectID := ""
+	if v.ProjectID != 0 {
+		projectID = strconv.Itoa(v.ProjectID)
+	}
+	return j.record(Entry{Mutation: Mutation{Op: OpDeleteVersion, ProjectID: projectID, VersionID: versionID, VersionName: v.Name}})
+}
+
+func (j *journaler) CreateMapping(projectID, componentID, versionID string) (jira.Mapping, error) {
+	m, err := j.Jira.CreateMapping(projectID, componentID, versionID)
+	if err != nil {
+		return m, err
+	}
+	p, _ := strconv.Atoi(projectID)
+	c, _ := strconv.Atoi(componentID)
+	v, _ := strconv.Atoi(versionID)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.mappings[m.ID] = jira.Mapping{ID: m.ID, ProjectID: p, ComponentID: c, VersionID: v}
+	return m, j.record(Entry{Mutation: Mutation{Op: OpCreateMapping, ProjectID: projectID, ComponentID: componentID, VersionID: versionID, VersionName: j.versions[versionID].Name, MappingID: m.ID}})
+}
+
+func (j *journaler) DeleteMapping(mappingID int) error {
+	if err := j.Jira.DeleteMapping(mappingID); err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	m := j.mappings[mappingID]
+	delete(j.mappings, mappingID)
+	return j.record(Entry{
+		Mutation: Mutation{
+			Op:          OpDeleteMapping,
+			ProjectID:   strconv.Itoa(m.ProjectID),
+			ComponentID: strconv.Itoa(m.ComponentID),
+			VersionID:   strconv.Itoa(m.VersionID),
+			VersionName: m.VersionName,
+			MappingID:   mappingID,
+		},
+		PreviousReleased:    m.Released,
+		PreviousReleaseDate: m.ReleaseDateStr,
+	})
+}
+
+func (j *journaler) UpdateReleasedFlag(mappingID int, released bool) error {
+	if err := j.Jira.UpdateReleasedFlag(mappingID, released); err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	m := j.mappings[mappingID]
+	previous := m.Released
+	m.Released = released
+	j.mappings[mappingID] = m
+	return j.record(Entry{Mutation: Mutation{Op: OpUpdateReleasedFlag, MappingID: mappingID, Released: &released}, PreviousReleased: previous})
+}
+
+func (j *journaler) UpdateReleaseDate(mappingID int, releaseDate string) error {
+	if err := j.Jira.UpdateReleaseDate(mappingID, releaseDate); err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	m := j.mappings[mappingID]
+	previous := m.ReleaseDateStr
+	m.ReleaseDateStr = releaseDate
+	j.mappings[mappingID] = m
+	return j.record(Entry{Mutation: Mutation{Op: OpUpdateReleaseDate, MappingID: mappingID, ReleaseDate: releaseDate}, PreviousReleaseDate: previous})
+}
+
+func (j *journaler) UpdateVersion(versionID string, update jira.VersionUpdate) (jira.Version, error) {
+	v, err := j.Jira.UpdateVersion(versionID, update)
+	if err != nil {
+		return v, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	previous := j.versions[versionID]
+	m := Mutation{Op: OpUpdateVersion, VersionID: versionID, VersionName: previous.Name, Released: update.Released}
+	if update.ReleaseDate != nil {
+		m.ReleaseDate = *update.ReleaseDate
+	}
+	if v.ID != "" {
+		j.versions[versionID] = v
+	}
+	return v, j.record(Entry{Mutation: m, PreviousReleased: previous.Released, PreviousReleaseDate: previous.ReleaseDate})
+}
+
+func (j *journaler) UpdateFixVersions(issueKey string, update jira.FixVersionsUpdate) error {
+	m, err := moveMutation(issueKey, update)
+	if err != nil {
+		return err
+	}
+	if err := j.Jira.UpdateFixVersions(issueKey, update); err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	m.VersionName = j.versions[m.VersionID].Name
+	m.FromVersionName = j.versions[m.FromVersionID].Name
+	return j.record(Entry{Mutation: m})
+}
+
+func (j *journaler) ReleaseVersion(versionID, releaseDate string) (jira.Version, error) {
+	released := true
+	return j.UpdateVersion(versionID, jira.VersionUpdate{Released: &released, ReleaseDate: &releaseDate})
+}
@@ -0,0 +1,267 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/xoom/kraken/jira"
+)
+
+func TestJournalAndUndo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kraken")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+
+	client := newFakeJira()
+	releaser := NewReleaser(client)
+	releaser.Journal = NewJournal(path, "run-1")
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", ReleaseDate: "1/Jan/15"}
+	if _, err := releaser.Release(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	entries, err := ReadJournal(path, "run-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("Want 6 but got %d\n", len(entries))
+	}
+	if entries[2].Op != OpUpdateReleasedFlag || entries[2].PreviousReleased {
+		t.Fatalf("Unexpected third entry: %+v\n", entries[2])
+	}
+
+	releaser.Journal = NewJournal(path, "run-2")
+	result, err := releaser.Undo(context.Background(), UndoRequest{JournalPath: path, RunID: "run-1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(result.Undone) != 6 {
+		t.Fatalf("Want 6 but got %d\n", len(result.Undone))
+	}
+	if len(client.versions) != 0 || len(client.mappings) != 0 {
+		t.Fatalf("Want no versions or mappings but got %+v and %+v\n", client.versions, client.mappings)
+	}
+
+	// The undo itself is journaled under its own run ID.
+	entries, err = ReadJournal(path, "run-2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("Want 6 but got %d\n", len(entries))
+	}
+}
+
+func TestUndoRecreatesDeletedMapping(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kraken")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+
+	client, released := releasedFakeJira(t)
+	releaser := NewReleaser(client)
+	releaser.Journal = NewJournal(path, "rollback")
+	req := RollbackRequest{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", DeleteNextMapping: true}
+	if _, err := releaser.Rollback(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	releaser.Journal = nil
+	if _, err := releaser.Undo(context.Background(), UndoRequest{JournalPath: path, RunID: "rollback"}); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	m := client.mappings[released.ReleaseMapping.ID]
+	if !m.Released || m.ReleaseDateStr != "1/Jan/15" {
+		t.Fatalf("Want released mapping dated 1/Jan/15 but got %+v\n", m)
+	}
+	if len(client.mappings) != 2 {
+		t.Fatalf("Want 2 but got %d\n", len(client.mappings))
+	}
+}
+
+func TestUndoRefusesVersionWithIssues(t *testing.T) {
+	client, released, releaser := journaledRelease(t)
+	undo := UndoRequest{JournalPath: releaser.Journal.Path, RunID: "release"}
+	releaser.Journal = nil
+	// Developers filed issues against the next version after the release.
+	client.issues[released.NextVersion.ID] = jira.IssueCounts{IssuesFixedCount: 1}
+
+	if _, err := releaser.Undo(context.Background(), undo); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+	if _, present := client.versions["1.2"]; !present {
+		t.Fatalf("Want next version 1.2 kept but got %+v\n", client.versions)
+	}
+	if _, present := client.mappings[released.ReleaseMapping.ID]; !present {
+		t.Fatalf("Want release mapping kept\n")
+	}
+
+	// Once the issues are moved elsewhere, undo runs again past the next mapping it already deleted.
+	delete(client.issues, released.NextVersion.ID)
+	result, err := releaser.Undo(context.Background(), undo)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(result.Undone) != 5 {
+		t.Fatalf("Want 5 but got %d\n", len(result.Undone))
+	}
+	if len(client.versions) != 0 || len(client.mappings) != 0 {
+		t.Fatalf("Want no versions or mappings but got %+v and %+v\n", client.versions, client.mappings)
+	}
+}
+
+func TestUndoRefusesVersionMappedToOtherComponent(t *testing.T) {
+	client, released, releaser := journaledRelease(t)
+	undo := UndoRequest{JournalPath: releaser.Journal.Path, RunID: "release"}
+	releaser.Journal = nil
+	versionID, _ := strconv.Atoi(released.NextVersion.ID)
+	client.mappings[500] = jira.Mapping{ID: 500, ProjectID: 1, ComponentID: 3, ComponentName: "web", VersionID: versionID}
+
+	if _, err := releaser.Undo(context.Background(), undo); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+	if _, present := client.versions["1.2"]; !present {
+		t.Fatalf("Want next version 1.2 kept but got %+v\n", client.versions)
+	}
+}
+
+func TestUndoResumes(t *testing.T) {
+	client, released, releaser := journaledRelease(t)
+	undo := UndoRequest{JournalPath: releaser.Journal.Path, RunID: "release"}
+	releaser.Journal = nil
+	// An earlier undo deleted the next mapping and version, and the release mapping, before it failed.
+	delete(client.mappings, released.NextMapping.ID)
+	delete(client.versions, "1.2")
+	delete(client.mappings, released.ReleaseMapping.ID)
+
+	result, err := releaser.Undo(context.Background(), undo)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(result.Undone) != 1 || result.Undone[0].Op != OpCreateVersion || result.Undone[0].VersionName != "1.1" {
+		t.Fatalf("Want only version 1.1 deleted but got %v\n", result.Undone)
+	}
+	if len(client.versions) != 0 {
+		t.Fatalf("Want no versions but got %+v\n", client.versions)
+	}
+}
+
+func TestUndoUnknownRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kraken")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+	if err := NewJournal(path, "run-1").Append(Entry{Mutation: Mutation{Op: OpDeleteMapping, MappingID: 1}}); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	if _, err := NewReleaser(newFakeJira()).Undo(context.Background(), UndoRequest{JournalPath: path, RunID: "run-2"}); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+}
+
+func TestUndoMovesIssuesBack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kraken")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+
+	client := newFakeJira()
+	client.versions["1.1"] = jira.Version{ID: "50", Name: "1.1"}
+	client.found[unresolvedJQL("1", "50", "2")] = []jira.Issue{{Key: "P-1"}}
+	client.fixVersions["P-1"] = []string{"50"}
+	releaser := NewReleaser(client)
+	releaser.Journal = NewJournal(path, "run-1")
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", ReleaseDate: "1/Jan/15", MoveUnresolved: true}
+	if _, err := releaser.Release(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	entries, err := ReadJournal(path, "run-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	// The issues are moved before the release mapping is released.
+	moved := entries[len(entries)-3]
+	if moved.Op != OpMoveIssue || moved.IssueKey != "P-1" || moved.FromVersionID != "50" || moved.FromVersionName != "1.1" || moved.VersionName != "1.2" {
+		t.Fatalf("Unexpected move entry: %+v\n", moved)
+	}
+	if last := entries[len(entries)-1]; last.Op != OpUpdateReleaseDate {
+		t.Fatalf("Unexpected last entry: %+v\n", last)
+	}
+
+	releaser.Journal = nil
+	if _, err := releaser.Undo(context.Background(), UndoRequest{JournalPath: path, RunID: "run-1"}); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if got := client.fixVersions["P-1"]; len(got) != 1 || got[0] != "50" {
+		t.Fatalf("Want P-1 back in version 50 but got %v\n", got)
+	}
+}
+
+// versionUpdates records the updates of UpdateVersion.
+type versionUpdates struct {
+	*fakeJira
+	updates []jira.VersionUpdate
+}
+
+func (v *versionUpdates) UpdateVersion(versionID string, update jira.VersionUpdate) (jira.Version, error) {
+	v.updates = append(v.updates, update)
+	return v.fakeJira.UpdateVersion(versionID, update)
+}
+
+func TestUndoJiraVersionRelease(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kraken")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.jsonl")
+
+	// A version without a release date gets none back, rather than an empty one.
+	for i, previousDate := range []string{"", "2014-12-31"} {
+		client := &versionUpdates{fakeJira: newFakeJira()}
+		client.versions["1.1"] = jira.Version{ID: "50", Name: "1.1", ReleaseDate: previousDate}
+		releaser := NewReleaser(client)
+		runID := fmt.Sprintf("run-%d", i)
+		releaser.Journal = NewJournal(path, runID)
+		req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", ReleaseDate: "2/Jan/15", ReleaseJiraVersion: true}
+		if _, err := releaser.Release(context.Background(), req); err != nil {
+			t.Fatalf("Unexpected error: %v\n", err)
+		}
+
+		releaser.Journal = nil
+		client.updates = nil
+		if _, err := releaser.Undo(context.Background(), UndoRequest{JournalPath: path, RunID: runID}); err != nil {
+			t.Fatalf("Unexpected error: %v\n", err)
+		}
+		if len(client.updates) != 1 {
+			t.Fatalf("Want 1 version update but got %d\n", len(client.updates))
+		}
+		update := client.updates[0]
+		if update.Released == nil || *update.Released {
+			t.Fatalf("Want the version marked unreleased but got %+v\n", update)
+		}
+		if previousDate == "" && update.ReleaseDate != nil || previousDate != "" && (update.ReleaseDate == nil || *update.ReleaseDate != previousDate) {
+			t.Fatalf("%q: unexpected release date in %+v\n", previousDate, update)
+		}
+		if client.versions["1.1"].Released {
+			t.Fatalf("Want the version unreleased but got %+v\n", client.versions["1.1"])
+		}
+	}
+}
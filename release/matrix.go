@@ -0,0 +1,98 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/xoom/kraken/jira"
+)
+
+// CellState is the state of a component's mapping to a version.
+type CellState string
+
+const (
+	CellReleased   CellState = "released"
+	CellUnreleased CellState = "unreleased"
+	CellUnmapped   CellState = "unmapped"
+)
+
+type (
+	// Matrix shows which components of a project are mapped to which versions, and whether they are released.
+	Matrix struct {
+		Project string `json:"project"`
+		// Versions are the project's versions in version order, the columns of the matrix.
+		Versions []string `json:"versions"`
+		// Rows are the project's components in name order.
+		Rows []MatrixRow `json:"rows"`
+	}
+
+	// MatrixRow holds the cells of a component, one per version of the matrix.
+	MatrixRow struct {
+		Component string `json:"component"`
+		Cells     []Cell `json:"cells"`
+	}
+
+	// Cell is a component's mapping to a version.
+	Cell struct {
+		State CellState `json:"state"`
+		// ReleaseDate is the release date of a released mapping.
+		ReleaseDate string `json:"releaseDate,omitempty"`
+	}
+)
+
+// Matrix reads the project's components, versions and mappings into a component by version matrix.  Archived
+// versions are left out unless includeArchived is set.  Matrix makes no changes to Jira.
+func (r *Releaser) Matrix(ctx context.Context, projectKey string, includeArchived bool) (Matrix, error) {
+	if projectKey == "" {
+		return Matrix{}, fmt.Errorf("project key must be provided")
+	}
+	client := jira.WithContext(ctx, r.client)
+
+	state, err := r.loadProject(client, projectKey)
+	if err != nil {
+		return Matrix{}, err
+	}
+	if state.mappings, err = r.loadMappings(client); err != nil {
+		return Matrix{}, err
+	}
+
+	var versions []jira.Version
+	for _, v := range state.versions {
+		if !v.Archived || includeArchived {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i].Name, versions[j].Name) < 0
+	})
+	var components []jira.Component
+	for _, c := range state.components {
+		components = append(components, c)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].Name < components[j].Name
+	})
+
+	mappings := state.projectMappings()
+	matrix := Matrix{Project: projectKey, Versions: []string{}, Rows: []MatrixRow{}}
+	for _, v := range versions {
+		matrix.Versions = append(matrix.Versions, v.Name)
+	}
+	for _, c := range components {
+		row := MatrixRow{Component: c.Name, Cells: []Cell{}}
+		for _, v := range versions {
+			cell := Cell{State: CellUnmapped}
+			if m, present := mappings[mappingKey{c.ID, v.ID}]; present {
+				cell.State = CellUnreleased
+				if m.Released {
+					cell = Cell{State: CellReleased, ReleaseDate: m.ReleaseDateStr}
+				}
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	r.Log.Printf("Found %d component(s), %d version(s) and %d mapping(s) of project %s\n", len(components), len(versions), len(mappings), projectKey)
+	return matrix, nil
+}
@@ -0,0 +1,58 @@
+package release
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xoom/kraken/jira"
+)
+
+func TestMatrix(t *testing.T) {
+	client, _ := releasedFakeJira(t)
+	client.components["web"] = jira.Component{ID: "3", Name: "web"}
+	client.versions["1.0"] = jira.Version{ID: "90", Name: "1.0", Archived: true}
+
+	matrix, err := NewReleaser(client).Matrix(context.Background(), "P", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(matrix.Versions) != 2 || matrix.Versions[0] != "1.1" || matrix.Versions[1] != "1.2" {
+		t.Fatalf("Want versions 1.1 and 1.2 but got %v\n", matrix.Versions)
+	}
+	if len(matrix.Rows) != 2 || matrix.Rows[0].Component != "rest-server" || matrix.Rows[1].Component != "web" {
+		t.Fatalf("Want rows rest-server and web but got %+v\n", matrix.Rows)
+	}
+	want := []Cell{{State: CellReleased, ReleaseDate: "1/Jan/15"}, {State: CellUnreleased}}
+	for i, cell := range matrix.Rows[0].Cells {
+		if cell != want[i] {
+			t.Fatalf("Want %+v but got %+v\n", want[i], cell)
+		}
+	}
+	if cell := matrix.Rows[1].Cells[0]; cell.State != CellUnmapped {
+		t.Fatalf("Want unmapped but got %+v\n", cell)
+	}
+
+	matrix, err = NewReleaser(client).Matrix(context.Background(), "P", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(matrix.Versions) != 3 || matrix.Versions[0] != "1.0" {
+		t.Fatalf("Want versions 1.0, 1.1 and 1.2 but got %v\n", matrix.Versions)
+	}
+}
+
+func TestMatrixIgnoresOtherProjects(t *testing.T) {
+	client, released := releasedFakeJira(t)
+	client.components["web"] = jira.Component{ID: "3", Name: "web"}
+	versionID := released.ReleaseMapping.VersionID
+	// Another project's mapping with the same component and version IDs.
+	client.mappings[500] = jira.Mapping{ID: 500, ProjectID: 2, ComponentID: 3, VersionID: versionID, Released: true}
+
+	matrix, err := NewReleaser(client).Matrix(context.Background(), "P", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if cell := matrix.Rows[1].Cells[0]; cell.State != CellUnmapped {
+		t.Fatalf("Want unmapped but got %+v\n", cell)
+	}
+}
@@ -0,0 +1,127 @@
+package release
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xoom/kraken/jira"
+)
+
+type (
+	// NotesRequest describes the release notes of a component version.
+	NotesRequest struct {
+		// ProjectKey is the Jira project key, for example PLAT.
+		ProjectKey string
+		// ComponentName is the Jira project component name, for example rest-server.
+		ComponentName string
+		// VersionName is the version whose resolved issues are listed, for example 1.1.
+		VersionName string
+	}
+
+	// Notes lists the resolved issues of a component version, grouped by issue type.
+	Notes struct {
+		Project   string `json:"project"`
+		Component string `json:"component"`
+		Version   string `json:"version"`
+		// ReleaseDate is the release date of the component's mapping to the version, empty if it is unreleased.
+		ReleaseDate string       `json:"releaseDate,omitempty"`
+		Groups      []IssueGroup `json:"groups"`
+	}
+
+	// IssueGroup holds the issues of one issue type, for example Bug.
+	IssueGroup struct {
+		Type   string      `json:"type"`
+		Issues []NoteIssue `json:"issues"`
+	}
+
+	// NoteIssue is an issue as listed in release notes.
+	NoteIssue struct {
+		Key        string `json:"key"`
+		Summary    string `json:"summary"`
+		Status     string `json:"status,omitempty"`
+		Resolution string `json:"resolution,omitempty"`
+	}
+)
+
+// notesJQL returns the JQL query for the resolved issues of the component with the version as fix version, in
+// Jira's issue type order.
+func notesJQL(projectID, versionID, componentID string) string {
+	return fmt.Sprintf("project = %s AND fixVersion = %s AND component = %s AND resolution IS NOT EMPTY ORDER BY issuetype, key", projectID, versionID, componentID)
+}
+
+// Notes reads the resolved issues of the component whose fix version is the requested version.  Groups are in
+// Jira's issue type order and issues in key order.  Notes makes no changes to Jira.
+func (r *Releaser) Notes(ctx context.Context, req NotesRequest) (Notes, error) {
+	if err := req.validate(); err != nil {
+		return Notes{}, err
+	}
+	client := jira.WithContext(ctx, r.client)
+
+	state, component, err := r.load(client, req.ProjectKey, req.ComponentName)
+	if err != nil {
+		return Notes{}, err
+	}
+	version, present := state.version(req.VersionName)
+	if !present {
+		return Notes{}, fmt.Errorf("version %s does not exist", req.VersionName)
+	}
+
+	return r.notes(client, state, req.ProjectKey, component, version)
+}
+
+// notes reads the release notes of the component version.
+func (r *Releaser) notes(client jira.Jira, state *projectState, projectKey string, component jira.Component, version jira.Version) (Notes, error) {
+	notes := Notes{Project: projectKey, Component: component.Name, Version: version.Name}
+	if m, present := state.mapping(component.ID, version.ID); present && m.Released {
+		notes.ReleaseDate = m.ReleaseDateStr
+	}
+
+	issues, err := client.Search(notesJQL(state.project.ID, version.ID, component.ID), []string{"summary", "issuetype", "status", "resolution"})
+	if err != nil {
+		return Notes{}, fmt.Errorf("error searching issues of version %s: %w", version.Name, err)
+	}
+	notes.Groups = groupIssues(issues)
+	r.Log.Printf("Found %d resolved issue(s) of component %s version %s\n", len(issues), component.Name, version.Name)
+	return notes, nil
+}
+
+// groupIssues groups issues by issue type, keeping the order in which the types and issues first appear.
+func groupIssues(issues []jira.Issue) []IssueGroup {
+	groups := []IssueGroup{}
+	index := make(map[string]int)
+	for _, issue := range issues {
+		f := issue.Fields
+		t := "Other"
+		if f.IssueType != nil && f.IssueType.Name != "" {
+			t = f.IssueType.Name
+		}
+		i, present := index[t]
+		if !present {
+			i = len(groups)
+			index[t] = i
+			groups = append(groups, IssueGroup{Type: t})
+		}
+		n := NoteIssue{Key: issue.Key, Summary: f.Summary}
+		if f.Status != nil {
+			n.Status = f.Status.Name
+		}
+		if f.Resolution != nil {
+			n.Resolution = f.Resolution.Name
+		}
+		groups[i].Issues = append(groups[i].Issues, n)
+	}
+	return groups
+}
+
+func (req NotesRequest) validate() error {
+	if req.ProjectKey == "" {
+		return fmt.Errorf("project key must be provided")
+	}
+	if req.ComponentName == "" {
+		return fmt.Errorf("component name must be provided")
+	}
+	if req.VersionName == "" {
+		return fmt.Errorf("version name must be provided")
+	}
+	return nil
+}
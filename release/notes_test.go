@@ -0,0 +1,44 @@
+package release
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xoom/kraken/jira"
+)
+
+func TestNotes(t *testing.T) {
+	client, released := releasedFakeJira(t)
+	bug := &jira.IssueType{Name: "Bug"}
+	story := &jira.IssueType{Name: "Story"}
+	client.found[notesJQL("1", released.ReleaseVersion.ID, "2")] = []jira.Issue{
+		{Key: "P-1", Fields: jira.IssueFields{Summary: "Crash", IssueType: bug, Resolution: &jira.Resolution{Name: "Fixed"}}},
+		{Key: "P-3", Fields: jira.IssueFields{Summary: "Login", IssueType: story}},
+		{Key: "P-2", Fields: jira.IssueFields{Summary: "Leak", IssueType: bug}},
+		{Key: "P-4", Fields: jira.IssueFields{Summary: "Untyped"}},
+	}
+
+	notes, err := NewReleaser(client).Notes(context.Background(), NotesRequest{ProjectKey: "P", ComponentName: "rest-server", VersionName: "1.1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if notes.ReleaseDate != "1/Jan/15" {
+		t.Fatalf("Want 1/Jan/15 but got %s\n", notes.ReleaseDate)
+	}
+	if len(notes.Groups) != 3 {
+		t.Fatalf("Want 3 groups but got %+v\n", notes.Groups)
+	}
+	if g := notes.Groups[0]; g.Type != "Bug" || len(g.Issues) != 2 || g.Issues[1].Key != "P-2" || g.Issues[0].Resolution != "Fixed" {
+		t.Fatalf("Unexpected first group: %+v\n", g)
+	}
+	if g := notes.Groups[2]; g.Type != "Other" || g.Issues[0].Key != "P-4" {
+		t.Fatalf("Unexpected last group: %+v\n", g)
+	}
+}
+
+func TestNotesMissingVersion(t *testing.T) {
+	client, _ := releasedFakeJira(t)
+	if _, err := NewReleaser(client).Notes(context.Background(), NotesRequest{ProjectKey: "P", ComponentName: "rest-server", VersionName: "9.9"}); err == nil {
+		t.Fatalf("Want error\n")
+	}
+}
@@ -0,0 +1,224 @@
+package release
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/xoom/kraken/jira"
+)
+
+type (
+	// A Mutation is a single Jira write a release would make.
+	Mutation struct {
+		Op          string `json:"op"`
+		ProjectID   string `json:"projectId,omitempty"`
+		ComponentID string `json:"componentId,omitempty"`
+		VersionID   string `json:"versionId,omitempty"`
+		VersionName string `json:"versionName,omitempty"`
+		MappingID   int    `json:"mappingId,omitempty"`
+		Released    *bool  `json:"released,omitempty"`
+		ReleaseDate string `json:"releaseDate,omitempty"`
+		// IssueKey, FromVersionID and FromVersionName describe an issue moved from one fix version to another.
+		IssueKey        string `json:"issueKey,omitempty"`
+		FromVersionID   string `json:"fromVersionId,omitempty"`
+		FromVersionName string `json:"fromVersionName,omitempty"`
+	}
+
+	// planner is a Jira client that passes reads through to the wrapped client and records writes instead of making them.
+	// Versions and mappings it pretends to create are given placeholder IDs so that later writes can refer to them.
+	planner struct {
+		jira.Jira
+		ids *placeholders
+
+		mu        sync.Mutex
+		mutations []Mutation
+	}
+
+	// placeholders hands out the placeholder IDs of a planner and its forks.
+	placeholders struct {
+		mu           sync.Mutex
+		versionNames map[string]string
+		created      int
+	}
+)
+
+// Mutation operations.
+const (
+	OpCreateVersion      = "create-version"
+	OpCreateMapping      = "create-mapping"
+	OpUpdateReleasedFlag = "update-released-flag"
+	OpUpdateReleaseDate  = "update-release-date"
+	OpDeleteMapping      = "delete-mapping"
+	OpDeleteVersion      = "delete-version"
+	OpUpdateVersion      = "update-version"
+	OpMoveIssue          = "move-issue"
+)
+
+func newPlanner(client jira.Jira) *planner {
+	return &planner{Jira: client, ids: &placeholders{versionNames: make(map[string]string)}}
+}
+
+// fork returns a planner that records its own plan but shares placeholder IDs with p, so that a version one of
+// them pretends to create can be used by the other.
+func (p *planner) fork() *planner {
+	return &planner{Jira: p.Jira, ids: p.ids}
+}
+
+func (p *planner) record(m Mutation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mutations = append(p.mutations, m)
+}
+
+// versionName returns the name of the version with the given real or placeholder ID.
+func (ids *placeholders) versionName(versionID string) string {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	return ids.versionNames[versionID]
+}
+
+func (ids *placeholders) newVersion(versionName string) string {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	ids.created++
+	id := fmt.Sprintf("new-%d", ids.created)
+	ids.versionNames[id] = versionName
+	return id
+}
+
+func (ids *placeholders) newMapping() int {
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+	ids.created++
+	return -ids.created
+}
+
+// GetVersions passes through to the wrapped client and remembers version names for describing mappings.
+func (p *planner) GetVersions(projectID string) (map[string]jira.Version, error) {
+	versions, err := p.Jira.GetVersions(projectID)
+	if err != nil {
+		return nil, err
+	}
+	p.ids.mu.Lock()
+	defer p.ids.mu.Unlock()
+	for _, v := range versions {
+		p.ids.versionNames[v.ID] = v.Name
+	}
+	return versions, nil
+}
+
+func (p *planner) CreateVersion(projectID, versionName string) (jira.Version, error) {
+	id := p.ids.newVersion(versionName)
+	p.record(Mutation{Op: OpCreateVersion, ProjectID: projectID, VersionName: versionName})
+	return jira.Version{ID: id, Name: versionName}, nil
+}
+
+func (p *planner) CreateMapping(projectID, componentID, versionID string) (jira.Mapping, error) {
+	versionName := p.ids.versionName(versionID)
+	m := Mutation{Op: OpCreateMapping, ProjectID: projectID, ComponentID: componentID, VersionName: versionName}
+	if !isPlaceholder(versionID) {
+		m.VersionID = versionID
+	}
+	p.record(m)
+	return jira.Mapping{ID: p.ids.newMapping(), VersionName: versionName}, nil
+}
+
+func (p *planner) UpdateReleasedFlag(mappingID int, released bool) error {
+	p.record(Mutation{Op: OpUpdateReleasedFlag, MappingID: mappingID, Released: &released})
+	return nil
+}
+
+func (p *planner) UpdateReleaseDate(mappingID int, releaseDate string) error {
+	p.record(Mutation{Op: OpUpdateReleaseDate, MappingID: mappingID, ReleaseDate: releaseDate})
+	return nil
+}
+
+func (p *planner) DeleteMapping(mappingID int) error {
+	p.record(Mutation{Op: OpDeleteMapping, MappingID: mappingID})
+	return nil
+}
+
+func (p *planner) DeleteVersion(versionID string) error {
+	p.record(Mutation{Op: OpDeleteVersion, VersionID: versionID, VersionName: p.ids.versionName(versionID)})
+	return nil
+}
+
+func (p *planner) UpdateVersion(versionID string, update jira.VersionUpdate) (jira.Version, error) {
+	m := Mutation{Op: OpUpdateVersion, VersionID: versionID, VersionName: p.ids.versionName(versionID), Released: update.Released}
+	if update.ReleaseDate != nil {
+		m.ReleaseDate = *update.ReleaseDate
+	}
+	p.record(m)
+	return jira.Version{ID: versionID, Name: m.VersionName}, nil
+}
+
+func (p *planner) ReleaseVersion(versionID, releaseDate string) (jira.Version, error) {
+	released := true
+	return p.UpdateVersion(versionID, jira.VersionUpdate{Released: &released, ReleaseDate: &releaseDate})
+}
+
+func (p *planner) UpdateFixVersions(issueKey string, update jira.FixVersionsUpdate) error {
+	m, err := moveMutation(issueKey, update)
+	if err != nil {
+		return err
+	}
+	m.VersionName = p.ids.versionName(m.VersionID)
+	m.FromVersionName = p.ids.versionName(m.FromVersionID)
+	if isPlaceholder(m.VersionID) {
+		m.VersionID = ""
+	}
+	p.record(m)
+	return nil
+}
+
+// Plan returns the recorded mutations in the order they would be made.
+func (p *planner) Plan() []Mutation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Mutation(nil), p.mutations...)
+}
+
+// String describes the mutation for a human reader.
+func (m Mutation) String() string {
+	switch m.Op {
+	case OpCreateVersion:
+		return fmt.Sprintf("create version %s in project %s", m.VersionName, m.ProjectID)
+	case OpCreateMapping:
+		return fmt.Sprintf("create mapping for project %s, component %s, version %s", m.ProjectID, m.ComponentID, m.VersionName)
+	case OpUpdateReleasedFlag:
+		return fmt.Sprintf("set released flag to %v on mapping %s", *m.Released, mappingLabel(m.MappingID))
+	case OpUpdateReleaseDate:
+		return fmt.Sprintf("set release date to %s on mapping %s", m.ReleaseDate, mappingLabel(m.MappingID))
+	case OpDeleteMapping:
+		return fmt.Sprintf("delete mapping %s", mappingLabel(m.MappingID))
+	case OpDeleteVersion:
+		return fmt.Sprintf("delete version %s", m.VersionName)
+	case OpUpdateVersion:
+		return fmt.Sprintf("set Jira released flag to %v and release date to %s on version %s", *m.Released, m.ReleaseDate, m.VersionName)
+	case OpMoveIssue:
+		return fmt.Sprintf("move issue %s from fix version %s to %s", m.IssueKey, m.FromVersionName, m.VersionName)
+	}
+	return m.Op
+}
+
+// moveMutation returns the mutation for a fix versions update that moves an issue from one version to another,
+// the only kind kraken makes.
+func moveMutation(issueKey string, update jira.FixVersionsUpdate) (Mutation, error) {
+	if len(update.Add) != 1 || len(update.Remove) != 1 {
+		return Mutation{}, fmt.Errorf("fix versions update of issue %s must move it from one version to another", issueKey)
+	}
+	return Mutation{Op: OpMoveIssue, IssueKey: issueKey, VersionID: update.Add[0], FromVersionID: update.Remove[0]}, nil
+}
+
+// Mappings the planner pretends to create have negative IDs.
+func mappingLabel(id int) string {
+	if id < 0 {
+		return "(to be created)"
+	}
+	return fmt.Sprintf("%d", id)
+}
+
+func isPlaceholder(versionID string) bool {
+	return strings.HasPrefix(versionID, "new-")
+}
@@ -0,0 +1,42 @@
+package release
+
+import "testing"
+
+func TestPlannerRecordsWrites(t *testing.T) {
+	p := newPlanner(nil)
+
+	v, err := p.CreateVersion("1", "2.2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	m, err := p.CreateMapping("1", "2", v.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if m.ID >= 0 {
+		t.Fatalf("Want a placeholder mapping ID but got %d\n", m.ID)
+	}
+	if err := p.UpdateReleasedFlag(m.ID, true); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if err := p.UpdateReleaseDate(7, "1/Jan/15"); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+
+	plan := p.Plan()
+	if len(plan) != 4 {
+		t.Fatalf("Want 4 but got %d\n", len(plan))
+	}
+	if plan[0].Op != OpCreateVersion || plan[0].VersionName != "2.2" {
+		t.Fatalf("Unexpected first mutation: %+v\n", plan[0])
+	}
+	if plan[1].Op != OpCreateMapping || plan[1].VersionName != "2.2" || plan[1].VersionID != "" {
+		t.Fatalf("Unexpected second mutation: %+v\n", plan[1])
+	}
+	if plan[2].Op != OpUpdateReleasedFlag || plan[2].MappingID != m.ID || !*plan[2].Released {
+		t.Fatalf("Unexpected third mutation: %+v\n", plan[2])
+	}
+	if plan[3].Op != OpUpdateReleaseDate || plan[3].MappingID != 7 || plan[3].ReleaseDate != "1/Jan/15" {
+		t.Fatalf("Unexpected fourth mutation: %+v\n", plan[3])
+	}
+}
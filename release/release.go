@@ -0,0 +1,408 @@
+// Package release implements the kraken release workflow against Jira and the Component Versions add-on.
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xoom/kraken/jira"
+)
+
+var (
+	// ErrProjectNotFound is returned when the requested Jira project does not exist.
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrComponentNotFound is returned when the requested component does not exist in the project.
+	ErrComponentNotFound = errors.New("component not found")
+	// ErrAlreadyReleased is returned when Request.FailIfReleased is set and the release mapping is released.
+	ErrAlreadyReleased = errors.New("already released")
+	// ErrUnresolvedIssues is returned when the release version has unresolved issues of the component and
+	// Request.AllowUnresolved is not set.
+	ErrUnresolvedIssues = errors.New("unresolved issues")
+)
+
+type (
+	// Releaser releases component versions through a Jira client.
+	Releaser struct {
+		client jira.Jira
+		Log    *log.Logger
+		// Journal records the writes the Releaser makes outside dry runs, and tells Rollback which versions
+		// kraken created.  Optional.
+		Journal *Journal
+		// DateFormat is the Jira date picker format of release dates, in Java SimpleDateFormat notation.
+		// Defaults to DefaultDateFormat.
+		DateFormat string
+		// Location is the time zone of the default release date, today.  Defaults to local time.
+		Location *time.Location
+	}
+
+	// Request describes a single component release.
+	Request struct {
+		// ProjectKey is the Jira project key, for example PLAT.
+		ProjectKey string
+		// ComponentName is the Jira project component name, for example rest-server.
+		ComponentName string
+		// ReleaseVersionName is the version to mark released, for example 1.1.
+		ReleaseVersionName string
+		// NextVersionName is the version to create and map as unreleased, for example 1.2.  Optional.
+		NextVersionName string
+		// ForceReleaseDate updates the release date of a release mapping that is already released.
+		ForceReleaseDate bool
+		// FailIfReleased makes Release return ErrAlreadyReleased, without making changes, if the release mapping
+		// is already released.
+		FailIfReleased bool
+		// ReleaseJiraVersion also marks the Jira version released, with the release date, once all of its
+		// component mappings are released.
+		ReleaseJiraVersion bool
+		// AllowUnresolved releases the version even if issues of the component with the version as fix version
+		// are unresolved.  Otherwise Release returns ErrUnresolvedIssues, without making changes.
+		AllowUnresolved bool
+		// MoveUnresolved releases the version even if issues of the component are unresolved, and moves those
+		// issues to the next version, which the request must have.
+		MoveUnresolved bool
+		// Bump computes the next version from ReleaseVersionName when NextVersionName is empty.  Optional.
+		Bump Bump
+		// BumpUnused makes Bump skip next versions that the project or the component has already released.
+		BumpUnused bool
+		// ReleaseDate is the release date in the Releaser's DateFormat.  Defaults to today.
+		ReleaseDate string
+		// DryRun records the writes Release would make in Result.Plan instead of making them.
+		DryRun bool
+	}
+
+	// Result reports what Release found in Jira and what it changed.
+	Result struct {
+		Project   jira.Project
+		Component jira.Component
+
+		ReleaseVersion        jira.Version
+		ReleaseVersionCreated bool
+		ReleaseMapping        jira.Mapping
+		ReleaseMappingCreated bool
+		// UnresolvedIssues holds the keys of the unresolved issues of the release version found before releasing it.
+		UnresolvedIssues []string
+		// MovedIssues holds the keys of the unresolved issues moved to the next version.
+		MovedIssues []string
+
+		// ReleasedFlagChanged is false when the release mapping was already released.
+		ReleasedFlagChanged bool
+		// ReleaseDateChanged reports whether ReleaseDate was written to the release mapping.
+		ReleaseDateChanged bool
+		ReleaseDate        string
+		// JiraVersionReleased reports whether the Jira version was marked released.
+		JiraVersionReleased bool
+
+		// The next version fields are zero when the request has no next version.
+		NextVersion        jira.Version
+		NextVersionCreated bool
+		NextMapping        jira.Mapping
+		NextMappingCreated bool
+
+		// Plan holds the writes a dry run would have made.
+		Plan []Mutation
+	}
+)
+
+// NewReleaser returns a Releaser that makes its Jira calls through client and logs to stderr.
+func NewReleaser(client jira.Jira) *Releaser {
+	return &Releaser{client: client, Log: log.New(os.Stderr, "", log.Ldate|log.Ltime|log.Lshortfile)}
+}
+
+// Release gets or creates the release version and its component mapping and marks the mapping released with
+// today's date, unless it is already released.  If a next version is requested, it gets or creates that version
+// and its unreleased mapping.  Running Release twice with the same request produces the same result.
+func (r *Releaser) Release(ctx context.Context, req Request) (Result, error) {
+	if err := req.validate(); err != nil {
+		return Result{}, err
+	}
+	if req.ReleaseDate == "" {
+		var err error
+		if req.ReleaseDate, err = r.today(); err != nil {
+			return Result{}, err
+		}
+	}
+
+	client, plan := r.clientFor(ctx, req.DryRun)
+
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	state, component, err := r.load(client, req.ProjectKey, req.ComponentName)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result, err := r.release(ctx, client, state, component, req)
+	if plan != nil {
+		result.Plan = plan.Plan()
+	}
+	return result, err
+}
+
+// release makes the changes of Release given what has been read from Jira.  req must be valid and have a release date.
+func (r *Releaser) release(ctx context.Context, client jira.Jira, state *projectState, component jira.Component, req Request) (Result, error) {
+	result := Result{Project: state.project, Component: component}
+
+	// Nothing has been written yet; stop here if the caller gave up while we were reading.
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	if err := r.checkUnresolved(client, state, component, req, &result); err != nil {
+		return result, err
+	}
+
+	var err error
+	// fetch or create release-version
+	result.ReleaseVersion, result.ReleaseVersionCreated, err = r.getOrCreateVersion(state, req.ReleaseVersionName, client)
+	if err != nil {
+		return result, fmt.Errorf("error getting or creating release version %s: %w", req.ReleaseVersionName, err)
+	}
+
+	// Create the release-version mapping if it does not exist
+	result.ReleaseMapping, result.ReleaseMappingCreated, err = r.getOrCreateMapping(state, result.Component.ID, result.ReleaseVersion.ID, client)
+	if err != nil {
+		return result, fmt.Errorf("error getting or creating release-version mapping: %w", err)
+	}
+
+	if result.ReleaseMapping.Released && req.FailIfReleased {
+		return result, fmt.Errorf("%w: component %s version %s on %s", ErrAlreadyReleased, component.Name, req.ReleaseVersionName, result.ReleaseMapping.ReleaseDateStr)
+	}
+
+	// Move the unresolved issues before marking the release mapping released, so that a failed move leaves the
+	// mapping as it was rather than released with unresolved issues.
+	moveFirst := req.MoveUnresolved && len(result.UnresolvedIssues) != 0
+	if moveFirst {
+		if err := r.getOrCreateNext(ctx, client, state, component, req, &result); err != nil {
+			return result, err
+		}
+		if err := r.moveIssues(ctx, client, &result); err != nil {
+			return result, err
+		}
+	}
+
+	// Do not update a mapping that is already released.
+	if !result.ReleaseMapping.Released {
+		if err = client.UpdateReleasedFlag(result.ReleaseMapping.ID, true); err != nil {
+			return result, fmt.Errorf("error updating release flag for release-version: %w", err)
+		}
+		result.ReleasedFlagChanged = true
+
+		if err = client.UpdateReleaseDate(result.ReleaseMapping.ID, req.ReleaseDate); err != nil {
+			return result, fmt.Errorf("error updating release date for release-version: %w", err)
+		}
+		result.ReleaseDateChanged = true
+		result.ReleaseDate = req.ReleaseDate
+		result.ReleaseMapping.Released = true
+		result.ReleaseMapping.ReleaseDateStr = req.ReleaseDate
+		state.putMapping(result.ReleaseMapping)
+		r.Log.Printf("Updated release date for release mapping %+v\n", result.ReleaseMapping)
+	} else if req.ForceReleaseDate && result.ReleaseMapping.ReleaseDateStr != req.ReleaseDate {
+		old := result.ReleaseMapping.ReleaseDateStr
+		if err = client.UpdateReleaseDate(result.ReleaseMapping.ID, req.ReleaseDate); err != nil {
+			return result, fmt.Errorf("error updating release date for release-version: %w", err)
+		}
+		result.ReleaseDateChanged = true
+		result.ReleaseDate = req.ReleaseDate
+		result.ReleaseMapping.ReleaseDateStr = req.ReleaseDate
+		state.putMapping(result.ReleaseMapping)
+		r.Log.Printf("Changed release date of already released release mapping %d from %s to %s\n", result.ReleaseMapping.ID, old, req.ReleaseDate)
+	} else {
+		result.ReleaseDate = result.ReleaseMapping.ReleaseDateStr
+		r.Log.Printf("Skipping already released release mapping: %+v\n", result.ReleaseMapping)
+	}
+
+	if req.ReleaseJiraVersion {
+		if err := r.releaseJiraVersion(client, state, &result, req.ReleaseDate); err != nil {
+			return result, err
+		}
+	}
+
+	if !moveFirst {
+		if err := r.getOrCreateNext(ctx, client, state, component, req, &result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// getOrCreateNext gets or creates the next version, named or computed by req, and the component's mapping to it.
+// It does nothing if req has no next version.
+func (r *Releaser) getOrCreateNext(ctx context.Context, client jira.Jira, state *projectState, component jira.Component, req Request, result *Result) error {
+	nextName, err := nextVersionName(state, component, req)
+	if err != nil {
+		return fmt.Errorf("error computing next version: %w", err)
+	}
+	if nextName == "" {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if nextName != req.NextVersionName {
+		r.Log.Printf("Computed next version %s\n", nextName)
+	}
+	result.NextVersion, result.NextVersionCreated, err = r.getOrCreateVersion(state, nextName, client)
+	if err != nil {
+		return fmt.Errorf("error creating next version %s: %w", nextName, err)
+	}
+
+	// Create the next-version mapping if it does not exist.
+	result.NextMapping, result.NextMappingCreated, err = r.getOrCreateMapping(state, result.Component.ID, result.NextVersion.ID, client)
+	if err != nil {
+		return fmt.Errorf("error creating next-version mapping: %w", err)
+	}
+	return nil
+}
+
+// releaseJiraVersion marks the Jira version of the result's release mapping released if it is not, and if every
+// component mapping to it is released.  The Jira version gets the mapping's release date, or requestDate if the
+// mapping, released by hand, has none or one in another format.
+func (r *Releaser) releaseJiraVersion(client jira.Jira, state *projectState, result *Result, requestDate string) error {
+	version := result.ReleaseVersion
+	if version.Released {
+		r.Log.Printf("Jira version %s is already released.\n", version.Name)
+		return nil
+	}
+	if unreleased := state.unreleasedMappings(version.ID); len(unreleased) != 0 {
+		r.Log.Printf("Not releasing Jira version %s, components %s are not released yet.\n", version.Name, strings.Join(unreleased, ", "))
+		return nil
+	}
+
+	date, err := r.isoDate(result.ReleaseDate)
+	if err != nil {
+		r.Log.Printf("Release mapping %d has no release date in the date format, releasing Jira version %s on %s\n", result.ReleaseMapping.ID, version.Name, requestDate)
+		if date, err = r.isoDate(requestDate); err != nil {
+			return err
+		}
+	}
+	if _, err := client.ReleaseVersion(version.ID, date); err != nil {
+		return fmt.Errorf("error releasing Jira version %s: %w", version.Name, err)
+	}
+	result.JiraVersionReleased = true
+	result.ReleaseVersion.Released = true
+	result.ReleaseVersion.ReleaseDate = date
+	state.putVersion(result.ReleaseVersion)
+	r.Log.Printf("Released Jira version %s on %s\n", version.Name, date)
+	return nil
+}
+
+// clientFor returns the client a workflow should make its Jira calls through.  In a dry run that is a planner
+// that records writes instead of making them; otherwise writes are journaled if the Releaser has a journal.  The
+// client's requests are bound to ctx if the Releaser's client supports contexts.
+func (r *Releaser) clientFor(ctx context.Context, dryRun bool) (jira.Jira, *planner) {
+	client := jira.WithContext(ctx, r.client)
+	if dryRun {
+		r.Log.Printf("Dry run: no changes will be made to Jira\n")
+		plan := newPlanner(client)
+		return plan, plan
+	}
+	if r.Journal != nil {
+		return newJournaler(client, r.Journal), nil
+	}
+	return client, nil
+}
+
+// detachedClient returns a client like client, the one clientFor returned, with its requests bound to a fresh
+// context that times out after timeout instead of to the workflow's context.  Compensating writes made after the
+// workflow's context is cancelled or times out go through it.  A planner is returned as is, since it makes no
+// requests.
+func (r *Releaser) detachedClient(client jira.Core, timeout time.Duration) (jira.Core, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	bound := jira.WithContext(ctx, r.client)
+	switch c := client.(type) {
+	case *planner:
+		return c, cancel
+	case *journaler:
+		return c.rebind(bound), cancel
+	}
+	return bound, cancel
+}
+
+// load reads the project, its versions and components, and the Component Versions mappings for all projects,
+// and looks up the component.
+func (r *Releaser) load(client jira.Jira, projectKey, componentName string) (*projectState, jira.Component, error) {
+	state, err := r.loadProject(client, projectKey)
+	if err != nil {
+		return nil, jira.Component{}, err
+	}
+	component, err := state.component(componentName)
+	if err != nil {
+		return nil, jira.Component{}, err
+	}
+	if state.mappings, err = r.loadMappings(client); err != nil {
+		return nil, jira.Component{}, err
+	}
+	return state, component, nil
+}
+
+// loadProject reads the project and its versions and components.
+func (r *Releaser) loadProject(client jira.Jira, projectKey string) (*projectState, error) {
+	project, err := client.GetProject(projectKey)
+	if jira.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: %s: %w", ErrProjectNotFound, projectKey, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting project %s: %w", projectKey, err)
+	}
+	r.Log.Printf("Found project: %s\n", projectKey)
+
+	versions, err := client.GetVersions(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting project versions: %w", err)
+	}
+	r.Log.Printf("Found %d project versions\n", len(versions))
+
+	components, err := client.GetComponents(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting project components: %w", err)
+	}
+	r.Log.Printf("Found %d project components\n", len(components))
+	return newProjectState(project, versions, components), nil
+}
+
+// loadMappings reads the mappings for all projects.
+func (r *Releaser) loadMappings(client jira.Jira) (map[int]jira.Mapping, error) {
+	mappings, err := client.GetMappings()
+	if err != nil {
+		return nil, fmt.Errorf("error getting mappings: %w", err)
+	}
+	return mappings, nil
+}
+
+// Changed reports whether Release made any change to Jira.
+func (r Result) Changed() bool {
+	return r.ReleaseVersionCreated || r.ReleaseMappingCreated || r.ReleasedFlagChanged || r.ReleaseDateChanged ||
+		r.JiraVersionReleased || r.NextVersionCreated || r.NextMappingCreated || len(r.MovedIssues) != 0
+}
+
+func (req Request) validate() error {
+	if req.ProjectKey == "" {
+		return fmt.Errorf("project key must be provided")
+	}
+	if req.ComponentName == "" {
+		return fmt.Errorf("component name must be provided")
+	}
+	if req.ReleaseVersionName == "" {
+		return fmt.Errorf("release version name must be provided")
+	}
+	if req.NextVersionName != "" && req.ReleaseVersionName == req.NextVersionName {
+		return fmt.Errorf("release version name and next version name must be different")
+	}
+	if req.MoveUnresolved && req.NextVersionName == "" && req.Bump == "" {
+		return fmt.Errorf("moving unresolved issues requires a next version")
+	}
+	if req.ForceReleaseDate && req.FailIfReleased {
+		return fmt.Errorf("force release date and fail if released are mutually exclusive")
+	}
+	if req.NextVersionName == "" && req.Bump != "" {
+		if _, err := BumpVersion(req.ReleaseVersionName, req.Bump); err != nil {
+			return err
+		}
+	}
+	return nil
+}
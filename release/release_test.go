@@ -0,0 +1,520 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xoom/kraken/jira"
+)
+
+// fakeJira is an in-memory Jira with the Component Versions add-on.
+type fakeJira struct {
+	project     jira.Project
+	components  map[string]jira.Component
+	versions    map[string]jira.Version
+	mappings    map[int]jira.Mapping
+	issues      map[string]jira.IssueCounts
+	found       map[string][]jira.Issue
+	fixVersions map[string][]string
+	failIssue   string
+	nextID      int
+	writes      int
+	mu          sync.Mutex
+	jira.Jira
+}
+
+func newFakeJira() *fakeJira {
+	return &fakeJira{
+		project:     jira.Project{ID: "1"},
+		components:  map[string]jira.Component{"rest-server": jira.Component{ID: "2", Name: "rest-server"}},
+		versions:    map[string]jira.Version{},
+		mappings:    map[int]jira.Mapping{},
+		issues:      map[string]jira.IssueCounts{},
+		found:       map[string][]jira.Issue{},
+		fixVersions: map[string][]string{},
+		nextID:      100,
+	}
+}
+
+func (r *fakeJira) GetProject(projectKey string) (jira.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.project, nil
+}
+
+func (r *fakeJira) GetComponents(projectID string) (map[string]jira.Component, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.components, nil
+}
+
+func (r *fakeJira) GetVersions(projectID string) (map[string]jira.Version, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := make(map[string]jira.Version)
+	for k, v := range r.versions {
+		m[k] = v
+	}
+	return m, nil
+}
+
+func (r *fakeJira) CreateVersion(projectID, versionName string) (jira.Version, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	r.nextID++
+	v := jira.Version{ID: strconv.Itoa(r.nextID), Name: versionName, Description: "Version " + versionName}
+	r.versions[versionName] = v
+	return v, nil
+}
+
+func (r *fakeJira) GetMappings() (map[int]jira.Mapping, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := make(map[int]jira.Mapping)
+	for k, v := range r.mappings {
+		m[k] = v
+	}
+	return m, nil
+}
+
+func (r *fakeJira) CreateMapping(projectID, componentID, versionID string) (jira.Mapping, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	r.nextID++
+	p, _ := strconv.Atoi(projectID)
+	c, _ := strconv.Atoi(componentID)
+	v, _ := strconv.Atoi(versionID)
+	m := jira.Mapping{ID: r.nextID, ProjectID: p, ComponentID: c, VersionID: v}
+	r.mappings[m.ID] = m
+	return jira.Mapping{ID: m.ID}, nil
+}
+
+func (r *fakeJira) UpdateReleasedFlag(mappingID int, released bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	m, present := r.mappings[mappingID]
+	if !present {
+		return &jira.APIError{Op: "updating mapping", StatusCode: 404}
+	}
+	m.Released = released
+	r.mappings[mappingID] = m
+	return nil
+}
+
+func (r *fakeJira) UpdateReleaseDate(mappingID int, releaseDate string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	m, present := r.mappings[mappingID]
+	if !present {
+		return &jira.APIError{Op: "updating mapping", StatusCode: 404}
+	}
+	m.ReleaseDateStr = releaseDate
+	r.mappings[mappingID] = m
+	return nil
+}
+
+func (r *fakeJira) DeleteVersion(versionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	for name, v := range r.versions {
+		if v.ID == versionID {
+			delete(r.versions, name)
+			return nil
+		}
+	}
+	return &jira.APIError{Op: "deleting version", StatusCode: 404}
+}
+
+func (r *fakeJira) UpdateVersion(versionID string, update jira.VersionUpdate) (jira.Version, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	for name, v := range r.versions {
+		if v.ID == versionID {
+			if update.Released != nil {
+				v.Released = *update.Released
+			}
+			if update.ReleaseDate != nil {
+				v.ReleaseDate = *update.ReleaseDate
+			}
+			r.versions[name] = v
+			return v, nil
+		}
+	}
+	return jira.Version{}, &jira.APIError{Op: "updating version", StatusCode: 404}
+}
+
+func (r *fakeJira) ReleaseVersion(versionID, releaseDate string) (jira.Version, error) {
+	released := true
+	return r.UpdateVersion(versionID, jira.VersionUpdate{Released: &released, ReleaseDate: &releaseDate})
+}
+
+func (r *fakeJira) GetVersionIssueCounts(versionID string) (jira.IssueCounts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.versions {
+		if v.ID == versionID {
+			return r.issues[versionID], nil
+		}
+	}
+	return jira.IssueCounts{}, &jira.APIError{Op: "getting issue counts", StatusCode: 404}
+}
+
+func (r *fakeJira) GetVersionsForComponent(projectID, componentID string) (map[int]jira.CVVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, _ := strconv.Atoi(componentID)
+	m := make(map[int]jira.CVVersion)
+	for _, mapping := range r.mappings {
+		if mapping.ComponentID != c {
+			continue
+		}
+		for _, v := range r.versions {
+			if v.ID == strconv.Itoa(mapping.VersionID) {
+				m[mapping.VersionID] = jira.CVVersion{ID: mapping.VersionID, Name: v.Name, Released: mapping.Released}
+			}
+		}
+	}
+	return m, nil
+}
+
+// Search returns the issues stored under the exact JQL query.
+func (r *fakeJira) Search(jql string, fields []string) ([]jira.Issue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.found[jql], nil
+}
+
+// UpdateFixVersions fails for the issue failIssue.
+func (r *fakeJira) UpdateFixVersions(issueKey string, update jira.FixVersionsUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if issueKey == r.failIssue {
+		return fmt.Errorf("cannot edit %s", issueKey)
+	}
+	r.writes++
+	var kept []string
+	for _, id := range r.fixVersions[issueKey] {
+		removed := false
+		for _, remove := range update.Remove {
+			removed = removed || id == remove
+		}
+		if !removed {
+			kept = append(kept, id)
+		}
+	}
+	r.fixVersions[issueKey] = append(kept, update.Add...)
+	return nil
+}
+
+func (r *fakeJira) DeleteMapping(mappingID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	if _, present := r.mappings[mappingID]; !present {
+		return &jira.APIError{Op: "deleting mapping", StatusCode: 404}
+	}
+	delete(r.mappings, mappingID)
+	return nil
+}
+
+func TestReleaserLogsToStderr(t *testing.T) {
+	// Programs using the package keep stdout for their own output.
+	if w := NewReleaser(newFakeJira()).Log.Writer(); w != os.Stderr {
+		t.Fatalf("Want logs on stderr but got %v\n", w)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	client := newFakeJira()
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", ReleaseDate: "1/Jan/15"}
+
+	result, err := NewReleaser(client).Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if !result.ReleaseVersionCreated || !result.ReleaseMappingCreated || !result.NextVersionCreated || !result.NextMappingCreated {
+		t.Fatalf("Want everything created but got %+v\n", result)
+	}
+	if !result.ReleasedFlagChanged || !result.ReleaseDateChanged {
+		t.Fatalf("Want flag and date changed but got %+v\n", result)
+	}
+	m := client.mappings[result.ReleaseMapping.ID]
+	if !m.Released || m.ReleaseDateStr != "1/Jan/15" {
+		t.Fatalf("Want released mapping dated 1/Jan/15 but got %+v\n", m)
+	}
+	if client.mappings[result.NextMapping.ID].Released {
+		t.Fatalf("Want next mapping unreleased\n")
+	}
+
+	// A second run finds everything and changes nothing.
+	writes := client.writes
+	req.ReleaseDate = "2/Jan/15"
+	result, err = NewReleaser(client).Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if result.ReleaseVersionCreated || result.ReleaseMappingCreated || result.NextVersionCreated || result.NextMappingCreated {
+		t.Fatalf("Want nothing created but got %+v\n", result)
+	}
+	if result.ReleasedFlagChanged || result.ReleaseDateChanged || result.ReleaseDate != "1/Jan/15" {
+		t.Fatalf("Want release mapping untouched but got %+v\n", result)
+	}
+	if client.writes != writes {
+		t.Fatalf("Want %d writes but got %d\n", writes, client.writes)
+	}
+}
+
+func TestReleaseDryRun(t *testing.T) {
+	client := newFakeJira()
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", DryRun: true}
+
+	result, err := NewReleaser(client).Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if client.writes != 0 {
+		t.Fatalf("Want 0 writes but got %d\n", client.writes)
+	}
+	if len(result.Plan) != 6 {
+		t.Fatalf("Want 6 but got %d\n", len(result.Plan))
+	}
+}
+
+func TestReleaseMissingComponent(t *testing.T) {
+	req := Request{ProjectKey: "P", ComponentName: "nope", ReleaseVersionName: "1.1"}
+	if _, err := NewReleaser(newFakeJira()).Release(context.Background(), req); !errors.Is(err, ErrComponentNotFound) {
+		t.Fatalf("Want ErrComponentNotFound but got %v\n", err)
+	}
+}
+
+func TestReleaseCanceled(t *testing.T) {
+	client := newFakeJira()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1"}
+	if _, err := NewReleaser(client).Release(ctx, req); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+	if client.writes != 0 {
+		t.Fatalf("Want 0 writes but got %d\n", client.writes)
+	}
+}
+
+func TestReleaseDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	baseURL, _ := url.Parse(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1"}
+	_, err := NewReleaser(jira.NewClient("u", "p", baseURL)).Release(ctx, req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Want context.DeadlineExceeded but got %v\n", err)
+	}
+}
+
+func TestReleaseMissingProject(t *testing.T) {
+	client := &missingProjectJira{newFakeJira()}
+	req := Request{ProjectKey: "NOPE", ComponentName: "rest-server", ReleaseVersionName: "1.1"}
+	_, err := NewReleaser(client).Release(context.Background(), req)
+	if !errors.Is(err, ErrProjectNotFound) {
+		t.Fatalf("Want ErrProjectNotFound but got %v\n", err)
+	}
+	if !jira.IsNotFound(err) {
+		t.Fatalf("Want the jira.APIError kept but got %v\n", err)
+	}
+}
+
+type missingProjectJira struct {
+	*fakeJira
+}
+
+func (r missingProjectJira) GetProject(projectKey string) (jira.Project, error) {
+	return jira.Project{}, &jira.APIError{Op: "getting project", StatusCode: 404}
+}
+
+func TestReleaseForceReleaseDate(t *testing.T) {
+	client, released := releasedFakeJira(t)
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", ReleaseDate: "3/Jan/15", ForceReleaseDate: true}
+
+	result, err := NewReleaser(client).Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if result.ReleasedFlagChanged || !result.ReleaseDateChanged || result.ReleaseDate != "3/Jan/15" {
+		t.Fatalf("Want only the release date changed but got %+v\n", result)
+	}
+	if got := client.mappings[released.ReleaseMapping.ID].ReleaseDateStr; got != "3/Jan/15" {
+		t.Fatalf("Want 3/Jan/15 but got %s\n", got)
+	}
+}
+
+func TestReleaseFailIfReleased(t *testing.T) {
+	client, _ := releasedFakeJira(t)
+	writes := client.writes
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.3", FailIfReleased: true}
+
+	_, err := NewReleaser(client).Release(context.Background(), req)
+	if !errors.Is(err, ErrAlreadyReleased) {
+		t.Fatalf("Want ErrAlreadyReleased but got %v\n", err)
+	}
+	if client.writes != writes {
+		t.Fatalf("Want %d writes but got %d\n", writes, client.writes)
+	}
+}
+
+func TestReleaseJiraVersion(t *testing.T) {
+	client := newFakeJira()
+	client.components["web"] = jira.Component{ID: "3", Name: "web"}
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", ReleaseDate: "2/Jan/15", ReleaseJiraVersion: true}
+
+	result, err := NewReleaser(client).Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if !result.JiraVersionReleased || !client.versions["1.1"].Released || client.versions["1.1"].ReleaseDate != "2015-01-02" {
+		t.Fatalf("Want the Jira version released on 2015-01-02 but got %+v\n", client.versions["1.1"])
+	}
+
+	// A component that has not shipped the version holds back the Jira version.
+	v := client.versions["1.1"]
+	v.Released = false
+	client.versions["1.1"] = v
+	client.mappings[90] = jira.Mapping{ID: 90, ProjectID: 1, ComponentID: 3, VersionID: 101, ComponentName: "web"}
+	result, err = NewReleaser(client).Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if result.JiraVersionReleased || client.versions["1.1"].Released {
+		t.Fatalf("Want the Jira version unreleased but got %+v\n", client.versions["1.1"])
+	}
+}
+
+func TestReleaseJiraVersionOfUndatedMapping(t *testing.T) {
+	for _, date := range []string{"", "2015-01-01"} {
+		client := newFakeJira()
+		client.versions["1.1"] = jira.Version{ID: "50", Name: "1.1"}
+		// Released by hand, without a date or with one in another format.
+		client.mappings[60] = jira.Mapping{ID: 60, ProjectID: 1, ComponentID: 2, VersionID: 50, Released: true, ReleaseDateStr: date}
+		req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", ReleaseDate: "2/Jan/15", ReleaseJiraVersion: true}
+
+		result, err := NewReleaser(client).Release(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v\n", date, err)
+		}
+		if !result.JiraVersionReleased || client.versions["1.1"].ReleaseDate != "2015-01-02" {
+			t.Fatalf("%q: want the Jira version released on 2015-01-02 but got %+v\n", date, client.versions["1.1"])
+		}
+		if !result.NextMappingCreated {
+			t.Fatalf("%q: want the next mapping created\n", date)
+		}
+	}
+}
+
+func TestReleaseUnresolvedIssues(t *testing.T) {
+	client := newFakeJira()
+	client.versions["1.1"] = jira.Version{ID: "50", Name: "1.1"}
+	client.found[unresolvedJQL("1", "50", "2")] = []jira.Issue{{Key: "P-1"}, {Key: "P-2"}}
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2"}
+
+	result, err := NewReleaser(client).Release(context.Background(), req)
+	if !errors.Is(err, ErrUnresolvedIssues) {
+		t.Fatalf("Want ErrUnresolvedIssues but got %v\n", err)
+	}
+	if client.writes != 0 {
+		t.Fatalf("Want no writes but got %d\n", client.writes)
+	}
+	if len(result.UnresolvedIssues) != 2 || result.UnresolvedIssues[0] != "P-1" {
+		t.Fatalf("Want P-1 and P-2 but got %v\n", result.UnresolvedIssues)
+	}
+
+	req.AllowUnresolved = true
+	result, err = NewReleaser(client).Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if !result.ReleasedFlagChanged || len(result.UnresolvedIssues) != 2 {
+		t.Fatalf("Want the mapping released with 2 unresolved issues but got %+v\n", result)
+	}
+
+	// The released mapping is not checked again.
+	req.AllowUnresolved = false
+	if _, err := NewReleaser(client).Release(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+}
+
+func TestReleaseMoveUnresolved(t *testing.T) {
+	client := newFakeJira()
+	client.versions["1.1"] = jira.Version{ID: "50", Name: "1.1"}
+	client.found[unresolvedJQL("1", "50", "2")] = []jira.Issue{{Key: "P-1"}, {Key: "P-2"}}
+	client.fixVersions["P-1"] = []string{"50", "40"}
+	client.fixVersions["P-2"] = []string{"50"}
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", MoveUnresolved: true}
+
+	result, err := NewReleaser(client).Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(result.MovedIssues) != 2 {
+		t.Fatalf("Want 2 moved issues but got %v\n", result.MovedIssues)
+	}
+	next := result.NextVersion.ID
+	// Other fix versions are kept.
+	if got := client.fixVersions["P-1"]; len(got) != 2 || got[0] != "40" || got[1] != next {
+		t.Fatalf("Want P-1 in versions 40 and %s but got %v\n", next, got)
+	}
+	if got := client.fixVersions["P-2"]; len(got) != 1 || got[0] != next {
+		t.Fatalf("Want P-2 in version %s but got %v\n", next, got)
+	}
+}
+
+func TestReleaseMoveUnresolvedRollsBack(t *testing.T) {
+	client := newFakeJira()
+	client.versions["1.1"] = jira.Version{ID: "50", Name: "1.1"}
+	client.found[unresolvedJQL("1", "50", "2")] = []jira.Issue{{Key: "P-1"}, {Key: "P-2"}}
+	client.fixVersions["P-1"] = []string{"50"}
+	client.fixVersions["P-2"] = []string{"50"}
+	client.failIssue = "P-2"
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", MoveUnresolved: true}
+
+	result, err := NewReleaser(client).Release(context.Background(), req)
+	if err == nil {
+		t.Fatalf("Want error\n")
+	}
+	if len(result.MovedIssues) != 0 {
+		t.Fatalf("Want no moved issues but got %v\n", result.MovedIssues)
+	}
+	if got := client.fixVersions["P-1"]; len(got) != 1 || got[0] != "50" {
+		t.Fatalf("Want P-1 moved back to version 50 but got %v\n", got)
+	}
+	// The release mapping is left unreleased.
+	if m := client.mappings[result.ReleaseMapping.ID]; m.Released || m.ReleaseDateStr != "" || result.ReleasedFlagChanged {
+		t.Fatalf("Want release mapping unreleased but got %+v\n", m)
+	}
+}
+
+func TestReleaseMoveUnresolvedNeedsNextVersion(t *testing.T) {
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", MoveUnresolved: true}
+	if _, err := NewReleaser(newFakeJira()).Release(context.Background(), req); err == nil {
+		t.Fatalf("Want error\n")
+	}
+}
@@ -0,0 +1,211 @@
+package release
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xoom/kraken/jira"
+)
+
+type (
+	// RollbackRequest describes undoing a component release.
+	RollbackRequest struct {
+		// ProjectKey is the Jira project key, for example PLAT.
+		ProjectKey string
+		// ComponentName is the Jira project component name, for example rest-server.
+		ComponentName string
+		// ReleaseVersionName is the released version to mark unreleased, for example 1.1.
+		ReleaseVersionName string
+		// NextVersionName is the next version created by the release, for example 1.2.  Optional.
+		NextVersionName string
+		// DeleteNextMapping deletes the component's mapping to the next version.
+		DeleteNextMapping bool
+		// DeleteNextVersion deletes the next version if the Releaser's journal records kraken creating it, no
+		// issues refer to it and no mapping other than the deleted next mapping refers to it.
+		DeleteNextVersion bool
+		// DryRun records the writes Rollback would make in RollbackResult.Plan instead of making them.
+		DryRun bool
+	}
+
+	// RollbackResult reports what Rollback changed.
+	RollbackResult struct {
+		Project   jira.Project
+		Component jira.Component
+
+		ReleaseVersion jira.Version
+		ReleaseMapping jira.Mapping
+
+		// ReleasedFlagChanged is false when the release mapping was not released.
+		ReleasedFlagChanged bool
+		// ReleaseDateCleared is false when the release mapping had no release date.
+		ReleaseDateCleared bool
+
+		NextMappingDeleted bool
+		NextVersionDeleted bool
+
+		// Plan holds the writes a dry run would have made.
+		Plan []Mutation
+	}
+)
+
+// Rollback marks the component's release-version mapping unreleased and clears its release date.  Optionally
+// it deletes the component's next-version mapping and the next version itself.  Rollback only changes mappings
+// and versions it finds; it returns an error rather than creating anything.  It leaves the Jira version released
+// by Request.ReleaseJiraVersion released, and issues moved by Request.MoveUnresolved where they are; undoing the
+// release run reverts those too.
+func (r *Releaser) Rollback(ctx context.Context, req RollbackRequest) (RollbackResult, error) {
+	if err := req.validate(); err != nil {
+		return RollbackResult{}, err
+	}
+
+	client, plan := r.clientFor(ctx, req.DryRun)
+
+	var result RollbackResult
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	state, component, err := r.load(client, req.ProjectKey, req.ComponentName)
+	if err != nil {
+		return result, err
+	}
+	result.Project = state.project
+	result.Component = component
+
+	var present bool
+	result.ReleaseVersion, present = state.versions[req.ReleaseVersionName]
+	if !present {
+		return result, fmt.Errorf("release version %s does not exist, refusing to roll back", req.ReleaseVersionName)
+	}
+	result.ReleaseMapping, present = state.mapping(component.ID, result.ReleaseVersion.ID)
+	if !present {
+		return result, fmt.Errorf("component %s has no mapping to version %s, refusing to roll back", req.ComponentName, req.ReleaseVersionName)
+	}
+
+	// Check the next version before writing anything so that a refusal leaves Jira untouched.
+	var nextVersion jira.Version
+	var nextMapping jira.Mapping
+	if req.DeleteNextMapping || req.DeleteNextVersion {
+		nextVersion, present = state.versions[req.NextVersionName]
+		if !present {
+			return result, fmt.Errorf("next version %s does not exist, refusing to roll back", req.NextVersionName)
+		}
+	}
+	if req.DeleteNextMapping {
+		nextMapping, present = state.mapping(component.ID, nextVersion.ID)
+		if !present {
+			return result, fmt.Errorf("component %s has no mapping to version %s, refusing to roll back", req.ComponentName, req.NextVersionName)
+		}
+	}
+	if req.DeleteNextVersion {
+		if err := r.checkVersionDeletable(client, nextVersion, state.mappings, nextMapping.ID); err != nil {
+			return result, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	if result.ReleaseMapping.Released {
+		if err := client.UpdateReleasedFlag(result.ReleaseMapping.ID, false); err != nil {
+			return result, fmt.Errorf("error updating release flag for release-version: %w", err)
+		}
+		result.ReleasedFlagChanged = true
+		r.Log.Printf("Marked release mapping %d unreleased\n", result.ReleaseMapping.ID)
+	} else {
+		r.Log.Printf("Release mapping %d is not released, no need to update its release flag.\n", result.ReleaseMapping.ID)
+	}
+
+	if result.ReleaseMapping.ReleaseDateStr != "" {
+		if err := client.UpdateReleaseDate(result.ReleaseMapping.ID, ""); err != nil {
+			return result, fmt.Errorf("error clearing release date for release-version: %w", err)
+		}
+		result.ReleaseDateCleared = true
+		r.Log.Printf("Cleared release date %s of release mapping %d\n", result.ReleaseMapping.ReleaseDateStr, result.ReleaseMapping.ID)
+	}
+
+	if req.DeleteNextMapping {
+		if err := client.DeleteMapping(nextMapping.ID); err != nil {
+			return result, fmt.Errorf("error deleting next-version mapping: %w", err)
+		}
+		result.NextMappingDeleted = true
+		r.Log.Printf("Deleted next-version mapping %d\n", nextMapping.ID)
+	}
+
+	if req.DeleteNextVersion {
+		if err := client.DeleteVersion(nextVersion.ID); err != nil {
+			return result, fmt.Errorf("error deleting next version %s: %w", req.NextVersionName, err)
+		}
+		result.NextVersionDeleted = true
+		r.Log.Printf("Deleted next version %s\n", nextVersion.Name)
+	}
+
+	if result.ReleaseVersion.Released {
+		r.Log.Printf("Jira version %s stays released.  Undo the release run to mark it unreleased.\n", result.ReleaseVersion.Name)
+	}
+
+	if plan != nil {
+		result.Plan = plan.Plan()
+	}
+	return result, nil
+}
+
+// Changed reports whether Rollback made any change to Jira.
+func (r RollbackResult) Changed() bool {
+	return r.ReleasedFlagChanged || r.ReleaseDateCleared || r.NextMappingDeleted || r.NextVersionDeleted
+}
+
+// checkVersionDeletable returns an error unless the journal records kraken creating the version, no issues refer
+// to it, and no mapping other than the one about to be deleted refers to it.
+func (r *Releaser) checkVersionDeletable(client jira.Jira, version jira.Version, mappings map[int]jira.Mapping, deletedMappingID int) error {
+	if r.Journal == nil {
+		return fmt.Errorf("no journal to tell whether kraken created next version %s, refusing to delete it", version.Name)
+	}
+	created, err := r.Journal.CreatedVersion(version.ID)
+	if err != nil {
+		return fmt.Errorf("error reading journal %s: %w", r.Journal.Path, err)
+	}
+	if !created {
+		return fmt.Errorf("journal %s does not record kraken creating next version %s, refusing to delete it", r.Journal.Path, version.Name)
+	}
+	return checkVersionUnused(client, version, mappings, map[int]bool{deletedMappingID: true})
+}
+
+// checkVersionUnused returns an error if issues refer to the version, or mappings other than the deleted ones.
+// Deleting a version in Jira removes it from the fix and affected versions of its issues, and leaves the mappings
+// of other components referring to a missing version.
+func checkVersionUnused(client jira.Jira, version jira.Version, mappings map[int]jira.Mapping, deletedMappingIDs map[int]bool) error {
+	counts, err := client.GetVersionIssueCounts(version.ID)
+	if err != nil {
+		return fmt.Errorf("error getting issue counts for version %s: %w", version.Name, err)
+	}
+	if counts.IssuesFixedCount != 0 || counts.IssuesAffectedCount != 0 {
+		return fmt.Errorf("version %s has %d fixed and %d affected issues, refusing to delete it", version.Name, counts.IssuesFixedCount, counts.IssuesAffectedCount)
+	}
+
+	for _, m := range mappings {
+		if fmt.Sprintf("%d", m.VersionID) == version.ID && !deletedMappingIDs[m.ID] {
+			return fmt.Errorf("version %s is mapped to component %s, refusing to delete it", version.Name, m.ComponentName)
+		}
+	}
+	return nil
+}
+
+func (req RollbackRequest) validate() error {
+	if req.ProjectKey == "" {
+		return fmt.Errorf("project key must be provided")
+	}
+	if req.ComponentName == "" {
+		return fmt.Errorf("component name must be provided")
+	}
+	if req.ReleaseVersionName == "" {
+		return fmt.Errorf("release version name must be provided")
+	}
+	if (req.DeleteNextMapping || req.DeleteNextVersion) && req.NextVersionName == "" {
+		return fmt.Errorf("next version name must be provided to delete the next mapping or version")
+	}
+	if req.NextVersionName != "" && req.ReleaseVersionName == req.NextVersionName {
+		return fmt.Errorf("release version name and next version name must be different")
+	}
+	return nil
+}
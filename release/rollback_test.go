@@ -0,0 +1,121 @@
+package release
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/xoom/kraken/jira"
+)
+
+func releasedFakeJira(t *testing.T) (*fakeJira, Result) {
+	client := newFakeJira()
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", ReleaseDate: "1/Jan/15"}
+	result, err := NewReleaser(client).Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	return client, result
+}
+
+// journaledRelease is releasedFakeJira with the release journaled, and returns a Releaser with that journal.
+func journaledRelease(t *testing.T) (*fakeJira, Result, *Releaser) {
+	client := newFakeJira()
+	releaser := NewReleaser(client)
+	releaser.Journal = NewJournal(filepath.Join(t.TempDir(), "journal.jsonl"), "release")
+	req := Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", ReleaseDate: "1/Jan/15"}
+	result, err := releaser.Release(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	releaser.Journal.RunID = "rollback"
+	return client, result, releaser
+}
+
+func TestRollback(t *testing.T) {
+	client, released, releaser := journaledRelease(t)
+
+	req := RollbackRequest{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", DeleteNextMapping: true, DeleteNextVersion: true}
+	result, err := releaser.Rollback(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if !result.ReleasedFlagChanged || !result.ReleaseDateCleared || !result.NextMappingDeleted || !result.NextVersionDeleted {
+		t.Fatalf("Want everything rolled back but got %+v\n", result)
+	}
+	m := client.mappings[released.ReleaseMapping.ID]
+	if m.Released || m.ReleaseDateStr != "" {
+		t.Fatalf("Want unreleased mapping without date but got %+v\n", m)
+	}
+	if _, present := client.mappings[released.NextMapping.ID]; present {
+		t.Fatalf("Want next mapping deleted\n")
+	}
+	if _, present := client.versions["1.2"]; present {
+		t.Fatalf("Want next version deleted\n")
+	}
+}
+
+func TestRollbackMissingMapping(t *testing.T) {
+	client := newFakeJira()
+	client.versions["1.1"] = jira.Version{ID: "7", Name: "1.1"}
+
+	req := RollbackRequest{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1"}
+	if _, err := NewReleaser(client).Rollback(context.Background(), req); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+	if client.writes != 0 {
+		t.Fatalf("Want 0 writes but got %d\n", client.writes)
+	}
+}
+
+func TestRollbackRefusesVersionWithIssues(t *testing.T) {
+	client, released, releaser := journaledRelease(t)
+	client.issues[released.NextVersion.ID] = jira.IssueCounts{IssuesFixedCount: 1}
+	writes := client.writes
+
+	req := RollbackRequest{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", DeleteNextMapping: true, DeleteNextVersion: true}
+	if _, err := releaser.Rollback(context.Background(), req); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+	if client.writes != writes {
+		t.Fatalf("Want %d writes but got %d\n", writes, client.writes)
+	}
+}
+
+func TestRollbackRefusesVersionNotCreatedByKraken(t *testing.T) {
+	// The next version has the description kraken gives the versions it creates, but no journal records it.
+	client, _ := releasedFakeJira(t)
+	if v := client.versions["1.2"]; v.Description != "Version 1.2" {
+		t.Fatalf("Want the description kraken gives but got %+v\n", v)
+	}
+	writes := client.writes
+
+	req := RollbackRequest{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", DeleteNextMapping: true, DeleteNextVersion: true}
+	releaser := NewReleaser(client)
+	if _, err := releaser.Rollback(context.Background(), req); err == nil {
+		t.Fatalf("Expected an error without a journal\n")
+	}
+	releaser.Journal = NewJournal(filepath.Join(t.TempDir(), "journal.jsonl"), "rollback")
+	if _, err := releaser.Rollback(context.Background(), req); err == nil {
+		t.Fatalf("Expected an error with a journal not recording the version\n")
+	}
+	if client.writes != writes {
+		t.Fatalf("Want %d writes but got %d\n", writes, client.writes)
+	}
+}
+
+func TestRollbackRefusesVersionMappedToOtherComponent(t *testing.T) {
+	client, released, releaser := journaledRelease(t)
+	versionID, _ := strconv.Atoi(released.NextVersion.ID)
+	client.mappings[500] = jira.Mapping{ID: 500, ProjectID: 1, ComponentID: 3, ComponentName: "web", VersionID: versionID}
+	writes := client.writes
+
+	req := RollbackRequest{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "1.1", NextVersionName: "1.2", DeleteNextMapping: true, DeleteNextVersion: true}
+	if _, err := releaser.Rollback(context.Background(), req); err == nil {
+		t.Fatalf("Expected an error\n")
+	}
+	if client.writes != writes {
+		t.Fatalf("Want %d writes but got %d\n", writes, client.writes)
+	}
+}
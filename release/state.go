@@ -0,0 +1,212 @@
+package release
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/xoom/kraken/jira"
+)
+
+type (
+	// projectState is what the workflows read from Jira before making changes.  The versions and mappings are
+	// kept up to date with the ones the workflows create.  The mappings are those of all projects and may be
+	// shared between the states of several projects, together with the locks that guard them.  A projectState
+	// is safe for concurrent use.
+	projectState struct {
+		project    jira.Project
+		components map[string]jira.Component
+
+		// mu guards versions and mappings.
+		mu       *sync.Mutex
+		versions map[string]jira.Version
+		mappings map[int]jira.Mapping
+
+		// keys serializes getting or creating the same version or mapping.
+		keys *keyLocks
+	}
+
+	// keyLocks is a set of mutexes identified by string keys.
+	keyLocks struct {
+		mu    sync.Mutex
+		locks map[string]*sync.Mutex
+	}
+)
+
+func newProjectState(project jira.Project, versions map[string]jira.Version, components map[string]jira.Component) *projectState {
+	return &projectState{
+		project:    project,
+		components: components,
+		mu:         &sync.Mutex{},
+		versions:   versions,
+		mappings:   make(map[int]jira.Mapping),
+		keys:       &keyLocks{locks: make(map[string]*sync.Mutex)},
+	}
+}
+
+// share makes s use the mappings and locks of other.
+func (s *projectState) share(other *projectState) {
+	s.mu = other.mu
+	s.mappings = other.mappings
+	s.keys = other.keys
+}
+
+func (s *projectState) component(name string) (jira.Component, error) {
+	component, present := s.components[name]
+	if !present {
+		return jira.Component{}, fmt.Errorf("%w: %s", ErrComponentNotFound, name)
+	}
+	return component, nil
+}
+
+func (s *projectState) version(name string) (jira.Version, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, present := s.versions[name]
+	return v, present
+}
+
+// released reports whether the named version exists and is released, either in Jira or for the component.
+func (s *projectState) released(component jira.Component, name string) bool {
+	v, present := s.version(name)
+	if !present {
+		return false
+	}
+	if v.Released {
+		return true
+	}
+	m, present := s.mapping(component.ID, v.ID)
+	return present && m.Released
+}
+
+// unreleasedMappings returns the names of the components whose mappings to the version are not released.
+func (s *projectState) unreleasedMappings(versionID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for _, m := range s.mappings {
+		if strconv.Itoa(m.VersionID) == versionID && !m.Released {
+			names = append(names, m.ComponentName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *projectState) putVersion(v jira.Version) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[v.Name] = v
+}
+
+func (s *projectState) mapping(componentID, versionID string) (jira.Mapping, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return findMapping(s.mappings, s.project.ID, componentID, versionID)
+}
+
+// mappingKey identifies the mapping of a component to a version.
+type mappingKey struct {
+	componentID, versionID string
+}
+
+// projectMappings returns the mappings of the state's project by component and version.  It scans the mappings of
+// all projects once, where mapping scans them on every call.
+func (s *projectState) projectMappings() map[mappingKey]jira.Mapping {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mappings := make(map[mappingKey]jira.Mapping)
+	for _, m := range s.mappings {
+		if strconv.Itoa(m.ProjectID) == s.project.ID {
+			mappings[mappingKey{strconv.Itoa(m.ComponentID), strconv.Itoa(m.VersionID)}] = m
+		}
+	}
+	return mappings
+}
+
+func (s *projectState) putMapping(m jira.Mapping) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mappings[m.ID] = m
+}
+
+// lockVersion serializes getting or creating the named version of the project.  Call the returned function to unlock.
+func (s *projectState) lockVersion(name string) func() {
+	return s.keys.lock(fmt.Sprintf("version %s %s", s.project.ID, name))
+}
+
+// lockMapping serializes getting or creating the project's mapping of the component and version.  Call the returned
+// function to unlock.
+func (s *projectState) lockMapping(componentID, versionID string) func() {
+	return s.keys.lock(fmt.Sprintf("mapping %s %s %s", s.project.ID, componentID, versionID))
+}
+
+func (k *keyLocks) lock(key string) func() {
+	k.mu.Lock()
+	l, present := k.locks[key]
+	if !present {
+		l = &sync.Mutex{}
+		k.locks[key] = l
+	}
+	k.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+func (r *Releaser) getOrCreateVersion(state *projectState, versionName string, client jira.Core) (jira.Version, bool, error) {
+	unlock := state.lockVersion(versionName)
+	defer unlock()
+
+	version, present := state.version(versionName)
+	if present {
+		r.Log.Printf("Retrieved existing version %s, no need to create it.\n", version.Name)
+		return version, false, nil
+	}
+
+	r.Log.Printf("Creating project version %s ...\n", versionName)
+	version, err := client.CreateVersion(state.project.ID, versionName)
+	if err != nil {
+		return jira.Version{}, false, err
+	}
+	r.Log.Printf("Created project version %s\n", version.Name)
+	state.putVersion(version)
+	return version, true, nil
+}
+
+func (r *Releaser) getOrCreateMapping(state *projectState, componentID, versionID string, client jira.ComponentVersions) (jira.Mapping, bool, error) {
+	unlock := state.lockMapping(componentID, versionID)
+	defer unlock()
+
+	mapping, present := state.mapping(componentID, versionID)
+	if present {
+		r.Log.Printf("Retrieved existing version mapping ID %d, no need to create it.\n", mapping.ID)
+		return mapping, false, nil
+	}
+
+	mapping, err := client.CreateMapping(state.project.ID, componentID, versionID)
+	if err != nil {
+		return jira.Mapping{}, false, err
+	}
+	r.Log.Printf("Created version mapping ID: %d\n", mapping.ID)
+
+	// CreateMapping returns only the new mapping's ID.
+	mapping.ProjectID, _ = strconv.Atoi(state.project.ID)
+	mapping.ComponentID, _ = strconv.Atoi(componentID)
+	mapping.VersionID, _ = strconv.Atoi(versionID)
+	state.putMapping(mapping)
+	return mapping, true, nil
+}
+
+func findMapping(mappings map[int]jira.Mapping, projectID, componentID, versionID string) (jira.Mapping, bool) {
+	for _, mapping := range mappings {
+		pID := fmt.Sprintf("%d", mapping.ProjectID)
+		cID := fmt.Sprintf("%d", mapping.ComponentID)
+		vID := fmt.Sprintf("%d", mapping.VersionID)
+		if pID == projectID && cID == componentID && vID == versionID {
+			return mapping, true
+		}
+	}
+	return jira.Mapping{}, false
+}
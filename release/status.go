@@ -0,0 +1,97 @@
+package release
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xoom/kraken/jira"
+)
+
+type (
+	// StatusRequest names the component whose release history Status reads.
+	StatusRequest struct {
+		// ProjectKey is the Jira project key, for example PLAT.
+		ProjectKey string
+		// ComponentName is the Jira project component name, for example rest-server.
+		ComponentName string
+	}
+
+	// ComponentStatus is the release history of a component.
+	ComponentStatus struct {
+		Project   string `json:"project"`
+		Component string `json:"component"`
+		// Versions are the versions mapped to the component, in version order.
+		Versions []VersionStatus `json:"versions"`
+		// LatestReleased is the last released version in version order, empty if none is released.
+		LatestReleased string `json:"latestReleased,omitempty"`
+		// NextUnreleased is the first unreleased version after LatestReleased, empty if there is none.
+		NextUnreleased string `json:"nextUnreleased,omitempty"`
+	}
+
+	// VersionStatus is a version mapped to a component.  Released and ReleaseDate are those of the component's
+	// mapping; the Jira fields are those of the Jira version.
+	VersionStatus struct {
+		Name            string `json:"name"`
+		ID              string `json:"id"`
+		MappingID       int    `json:"mappingId,omitempty"`
+		Released        bool   `json:"released"`
+		ReleaseDate     string `json:"releaseDate,omitempty"`
+		JiraReleased    bool   `json:"jiraReleased"`
+		JiraArchived    bool   `json:"jiraArchived"`
+		JiraReleaseDate string `json:"jiraReleaseDate,omitempty"`
+	}
+)
+
+// Status reads the versions mapped to the component and their released state.  Status makes no changes to Jira.
+func (r *Releaser) Status(ctx context.Context, req StatusRequest) (ComponentStatus, error) {
+	if err := req.validate(); err != nil {
+		return ComponentStatus{}, err
+	}
+	client := jira.WithContext(ctx, r.client)
+
+	state, component, err := r.load(client, req.ProjectKey, req.ComponentName)
+	if err != nil {
+		return ComponentStatus{}, err
+	}
+	versions, err := r.componentVersions(client, state, component)
+	if err != nil {
+		return ComponentStatus{}, err
+	}
+	sortVersions(versions, OrderVersion)
+
+	status := ComponentStatus{Project: req.ProjectKey, Component: component.Name, Versions: []VersionStatus{}}
+	latest := -1
+	for i, cv := range versions {
+		v := cv.version
+		s := VersionStatus{Name: v.Name, ID: v.ID, JiraReleased: v.Released, JiraArchived: v.Archived, JiraReleaseDate: v.ReleaseDate}
+		if m, present := state.mapping(component.ID, v.ID); present {
+			s.MappingID = m.ID
+			s.Released = m.Released
+			s.ReleaseDate = m.ReleaseDateStr
+		}
+		if s.Released {
+			latest = i
+		}
+		status.Versions = append(status.Versions, s)
+	}
+	if latest >= 0 {
+		status.LatestReleased = status.Versions[latest].Name
+	}
+	for _, s := range status.Versions[latest+1:] {
+		if !s.Released {
+			status.NextUnreleased = s.Name
+			break
+		}
+	}
+	return status, nil
+}
+
+func (req StatusRequest) validate() error {
+	if req.ProjectKey == "" {
+		return fmt.Errorf("project key must be provided")
+	}
+	if req.ComponentName == "" {
+		return fmt.Errorf("component name must be provided")
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package release
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	client := diffFakeJira(t)
+	// Map 2.11 unreleased and archive 2.2.
+	if _, err := NewReleaser(client).Release(context.Background(), Request{ProjectKey: "P", ComponentName: "rest-server", ReleaseVersionName: "2.10", NextVersionName: "2.11"}); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	v := client.versions["2.2"]
+	v.Archived = true
+	client.versions["2.2"] = v
+
+	status, err := NewReleaser(client).Status(context.Background(), StatusRequest{ProjectKey: "P", ComponentName: "rest-server"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if len(status.Versions) != 5 {
+		t.Fatalf("Want 5 versions but got %+v\n", status.Versions)
+	}
+	if s := status.Versions[1]; s.Name != "2.2" || !s.Released || s.ReleaseDate != "2/Jan/15" || !s.JiraArchived || s.MappingID == 0 {
+		t.Fatalf("Unexpected 2.2 status: %+v\n", s)
+	}
+	if s := status.Versions[4]; s.Name != "2.11" || s.Released {
+		t.Fatalf("Unexpected 2.11 status: %+v\n", s)
+	}
+	if status.LatestReleased != "2.10" || status.NextUnreleased != "2.11" {
+		t.Fatalf("Want latest 2.10 and next 2.11 but got %s and %s\n", status.LatestReleased, status.NextUnreleased)
+	}
+}
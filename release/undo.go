@@ -0,0 +1,173 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/xoom/kraken/jira"
+)
+
+type (
+	// UndoRequest describes undoing the journaled writes of an earlier run.
+	UndoRequest struct {
+		// JournalPath is the journal file the earlier run appended to.
+		JournalPath string
+		// RunID identifies the earlier run in the journal.
+		RunID string
+		// DryRun records the writes Undo would make in UndoResult.Plan instead of making them.
+		DryRun bool
+	}
+
+	// UndoResult reports the journal entries Undo reverted, most recent first.
+	UndoResult struct {
+		Undone []Entry
+
+		// Plan holds the writes a dry run would have made.
+		Plan []Mutation
+	}
+)
+
+// Undo reverts the journaled writes of an earlier run in reverse order: created versions and mappings are
+// deleted, released flags and release dates of mappings and versions are set back to their previous values, moved
+// issues are moved back, and deleted mappings and versions are created again.  Recreated versions and mappings get
+// new IDs, which Undo substitutes in the entries that follow.  The writes Undo makes are themselves journaled under
+// the Releaser's journal run ID.
+//
+// Undo stops with an error rather than delete a created version that issues, or mappings it is not deleting,
+// refer to.  Versions and mappings that are already deleted are skipped, so an Undo that failed can be run again.
+func (r *Releaser) Undo(ctx context.Context, req UndoRequest) (UndoResult, error) {
+	if req.JournalPath == "" {
+		return UndoResult{}, fmt.Errorf("journal path must be provided")
+	}
+	if req.RunID == "" {
+		return UndoResult{}, fmt.Errorf("run ID must be provided")
+	}
+
+	var result UndoResult
+	entries, err := ReadJournal(req.JournalPath, req.RunID)
+	if err != nil {
+		return result, err
+	}
+	if len(entries) == 0 {
+		return result, fmt.Errorf("journal %s has no entries for run %s", req.JournalPath, req.RunID)
+	}
+	r.Log.Printf("Undoing %d journaled change(s) of run %s\n", len(entries), req.RunID)
+
+	client, plan := r.clientFor(ctx, req.DryRun)
+
+	// Old to new IDs of versions and mappings recreated by this undo.
+	versionIDs := make(map[string]string)
+	mappingIDs := make(map[int]int)
+	versionID := func(id string) string {
+		if n, present := versionIDs[id]; present {
+			return n
+		}
+		return id
+	}
+	mappingID := func(id int) int {
+		if n, present := mappingIDs[id]; present {
+			return n
+		}
+		return id
+	}
+	// Mappings deleted by this undo, which no longer keep a created version.
+	deletedMappingIDs := make(map[int]bool)
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		e := entries[i]
+		switch e.Op {
+		case OpCreateVersion:
+			err = r.undoCreateVersion(client, jira.Version{ID: versionID(e.VersionID), Name: e.VersionName}, deletedMappingIDs)
+		case OpCreateMapping:
+			err = client.DeleteMapping(mappingID(e.MappingID))
+			deletedMappingIDs[mappingID(e.MappingID)] = true
+		case OpUpdateReleasedFlag:
+			err = client.UpdateReleasedFlag(mappingID(e.MappingID), e.PreviousReleased)
+		case OpUpdateReleaseDate:
+			err = client.UpdateReleaseDate(mappingID(e.MappingID), e.PreviousReleaseDate)
+		case OpUpdateVersion:
+			update := jira.VersionUpdate{Released: &e.PreviousReleased}
+			// Jira rejects an empty release date, so a version that had none is left without the field.
+			if e.PreviousReleaseDate != "" {
+				update.ReleaseDate = &e.PreviousReleaseDate
+			}
+			_, err = client.UpdateVersion(versionID(e.VersionID), update)
+		case OpMoveIssue:
+			err = client.UpdateFixVersions(e.IssueKey, jira.FixVersionsUpdate{Add: []string{versionID(e.FromVersionID)}, Remove: []string{versionID(e.VersionID)}})
+		case OpDeleteVersion:
+			if e.ProjectID == "" {
+				err = fmt.Errorf("journal entry has no project ID")
+				break
+			}
+			v, cerr := client.CreateVersion(e.ProjectID, e.VersionName)
+			if err = cerr; err == nil {
+				versionIDs[e.VersionID] = v.ID
+			}
+		case OpDeleteMapping:
+			m, cerr := client.CreateMapping(e.ProjectID, e.ComponentID, versionID(e.VersionID))
+			if err = cerr; err != nil {
+				break
+			}
+			mappingIDs[e.MappingID] = m.ID
+			if e.PreviousReleased {
+				if err = client.UpdateReleasedFlag(m.ID, true); err != nil {
+					break
+				}
+			}
+			if e.PreviousReleaseDate != "" {
+				err = client.UpdateReleaseDate(m.ID, e.PreviousReleaseDate)
+			}
+		default:
+			err = fmt.Errorf("unknown journal operation %s", e.Op)
+		}
+		switch e.Op {
+		case OpCreateVersion, OpCreateMapping, OpUpdateReleasedFlag, OpUpdateReleaseDate, OpUpdateVersion:
+			// An earlier undo of the run, or someone else, already deleted the version or mapping.
+			if jira.IsNotFound(err) {
+				r.Log.Printf("Skipping %s, already deleted\n", e)
+				continue
+			}
+		}
+		if err != nil {
+			return result, fmt.Errorf("error undoing %s: %w", e, err)
+		}
+
+		r.Log.Printf("Undid %s\n", e)
+		result.Undone = append(result.Undone, e)
+	}
+
+	if plan != nil {
+		result.Plan = plan.Plan()
+	}
+	return result, nil
+}
+
+// undoCreateVersion deletes a version the undone run created, unless issues or mappings not in deletedMappingIDs
+// refer to it.
+func (r *Releaser) undoCreateVersion(client jira.Jira, version jira.Version, deletedMappingIDs map[int]bool) error {
+	mappings, err := r.loadMappings(client)
+	if err != nil {
+		return err
+	}
+	if err := checkVersionUnused(client, version, mappings, deletedMappingIDs); err != nil {
+		return err
+	}
+	return client.DeleteVersion(version.ID)
+}
+
+func (e Entry) String() string {
+	switch e.Op {
+	case OpUpdateReleasedFlag:
+		return fmt.Sprintf("%s (was %v)", e.Mutation, e.PreviousReleased)
+	case OpUpdateReleaseDate:
+		return fmt.Sprintf("%s (was %s)", e.Mutation, strconv.Quote(e.PreviousReleaseDate))
+	case OpUpdateVersion:
+		return fmt.Sprintf("%s (was %v, %s)", e.Mutation, e.PreviousReleased, strconv.Quote(e.PreviousReleaseDate))
+	}
+	return e.Mutation.String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/xoom/kraken/release"
+)
+
+// printStatus writes the component's release history to w as a table, or as JSON for the json format.
+func printStatus(w io.Writer, status release.ComponentStatus, format string) error {
+	if format == "json" {
+		data, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tRELEASED\tRELEASE DATE\tJIRA RELEASED\tJIRA ARCHIVED")
+	for _, v := range status.Versions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", v.Name, yesNo(v.Released), dash(v.ReleaseDate), yesNo(v.JiraReleased), yesNo(v.JiraArchived))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nLatest released: %s\nNext unreleased: %s\n", dash(status.LatestReleased), dash(status.NextUnreleased))
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// dash returns s, or a dash if s is empty.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func runStatus(ctx context.Context, releaser *release.Releaser) {
+	resolveComponent()
+	status, err := releaser.Status(ctx, release.StatusRequest{ProjectKey: *projectKey, ComponentName: *componentName})
+	if err != nil {
+		fail(err, false)
+	}
+	err = writeOutput(*output, "status", func(w io.Writer) error {
+		return printStatus(w, status, *format)
+	})
+	if err != nil {
+		fail(err, false)
+	}
+}
+
+// validateStatus validates the flags of the status command.
+func validateStatus() []error {
+	errors := validateJira()
+	errors = append(errors, validateComponent()...)
+	switch *format {
+	case "", "table", "json":
+	default:
+		errors = append(errors, fmt.Errorf("format must be table or json for status"))
+	}
+	if *manifestPath != "" {
+		errors = append(errors, fmt.Errorf("manifest is not supported by status"))
+	}
+	return errors
+}
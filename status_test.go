@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/xoom/kraken/release"
+)
+
+var testStatus = release.ComponentStatus{
+	Project:   "P",
+	Component: "rest-server",
+	Versions: []release.VersionStatus{
+		{Name: "2.10", ID: "10", Released: true, ReleaseDate: "3/Jan/15", JiraArchived: true},
+		{Name: "2.11", ID: "11"},
+	},
+	LatestReleased: "2.10",
+	NextUnreleased: "2.11",
+}
+
+func TestPrintStatus(t *testing.T) {
+	var b bytes.Buffer
+	if err := printStatus(&b, testStatus, ""); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	for _, want := range []string{
+		"VERSION  RELEASED  RELEASE DATE  JIRA RELEASED  JIRA ARCHIVED\n",
+		"2.10     yes       3/Jan/15      no             yes\n",
+		"2.11     no        -             no             no\n",
+		"Latest released: 2.10\nNext unreleased: 2.11\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Fatalf("Want %q in\n%s\n", want, b.String())
+		}
+	}
+}
+
+func TestPrintStatusJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := printStatus(&b, testStatus, "json"); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	var got release.ComponentStatus
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("Unexpected error: %v\n", err)
+	}
+	if got.LatestReleased != "2.10" || len(got.Versions) != 2 || !got.Versions[0].JiraArchived {
+		t.Fatalf("Unexpected status: %+v\n", got)
+	}
+}
+
+func TestStatusStdout(t *testing.T) {
+	out := runKraken(t, "status", "-component-name", "rest-server", "-format", "json")
+	var status release.ComponentStatus
+	if err := json.Unmarshal(out, &status); err != nil {
+		t.Fatalf("Want only the status on stdout but got %v:\n%s\n", err, out)
+	}
+	if status.LatestReleased != "2.1" || status.NextUnreleased != "2.2" {
+		t.Fatalf("Unexpected status: %+v\n", status)
+	}
+}
@@ -1,6 +1,10 @@
 package main
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func TestValidate(t *testing.T) {
 	errors := validate()
@@ -17,3 +21,44 @@ func TestValidate(t *testing.T) {
 		t.Fatalf("Want 5 but got %d\n", len(errors))
 	}
 }
+
+func TestValidateComponent(t *testing.T) {
+	defer func(p, c, j string) { *projectKey, *componentName, *jobName = p, c, j }(*projectKey, *componentName, *jobName)
+
+	*projectKey, *componentName, *jobName = "", "", ""
+	if errors := validateComponent(); len(errors) != 2 {
+		t.Fatalf("Want 2 but got %v\n", errors)
+	}
+	*projectKey, *componentName, *jobName = "P", "rest-server", "eng-rest-server-release"
+	if errors := validateComponent(); len(errors) != 1 || !strings.HasPrefix(errors[0].Error(), "only one of component-name") {
+		t.Fatalf("Want only both names but got %v\n", errors)
+	}
+	// The notes, diff and status commands check the component as release does.
+	for _, check := range []func() []error{validateNotes, validateDiff, validateStatus} {
+		if errors := check(); len(errors) == 0 || !strings.Contains(fmt.Sprint(errors), "only one of component-name") {
+			t.Fatalf("Want both names refused but got %v\n", errors)
+		}
+	}
+}
+
+func TestValidateUndo(t *testing.T) {
+	errors := validateUndo()
+	if len(errors) != 3 {
+		t.Fatalf("Want 3 but got %d\n", len(errors))
+	}
+}
+
+func TestValidateAuth(t *testing.T) {
+	defer func(m string) { *authMethod = m }(*authMethod)
+
+	*authMethod = "bearer"
+	errors := validateJira()
+	if len(errors) != 1 || !strings.HasPrefix(errors[0].Error(), "jira-token must be provided") {
+		t.Fatalf("Want only the missing token but got %v\n", errors)
+	}
+
+	*authMethod = "kerberos"
+	if errors := validateJira(); len(errors) != 1 {
+		t.Fatalf("Want 1 but got %d\n", len(errors))
+	}
+}